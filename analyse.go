@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"log"
 	"math"
+	"sort"
+	"sync"
 
 	"github.com/dayvonjersen/linguist/data"
 	"github.com/dayvonjersen/linguist/tokenizer"
@@ -11,7 +13,7 @@ import (
 )
 
 var classifier *bayesian.Classifier
-var classifier_initialized bool = false
+var classifier_once sync.Once
 
 // Gets the baysian.Classifier which has been trained on programming language
 // samples from github.com/github/linguist after running the generator
@@ -21,7 +23,7 @@ func getClassifier() *bayesian.Classifier {
 	// NOTE(tso): this could probably go into an init() function instead
 	// but this lazy loading approach works, and it's conceivable that the
 	// analyse() function might not invoked in an actual runtime anyway
-	if !classifier_initialized {
+	classifier_once.Do(func() {
 		data, err := data.Asset("classifier")
 		if err != nil {
 			log.Panicln(err)
@@ -31,8 +33,7 @@ func getClassifier() *bayesian.Classifier {
 		if err != nil {
 			log.Panicln(err)
 		}
-		classifier_initialized = true
-	}
+	})
 	return classifier
 }
 
@@ -47,7 +48,17 @@ func getClassifier() *bayesian.Classifier {
 //
 // NOTE(tso): May yield inaccurate results
 func Analyse(contents []byte, hints []string) (language string) {
+	return analyse(contents, hints, 0, 0)
+}
+
+// analyse is Analyse, considering only the first maxTokens tokens of contents
+// and choosing only among the maxCandidates languages with the highest scores
+// (the best of those among hints, if any), either unlimited if 0.
+func analyse(contents []byte, hints []string, maxCandidates, maxTokens int) (language string) {
 	document := tokenizer.Tokenize(contents)
+	if maxTokens > 0 && len(document) > maxTokens {
+		document = document[:maxTokens]
+	}
 	classifier := getClassifier()
 	scores, idx, _ := classifier.LogScores(document)
 
@@ -55,6 +66,13 @@ func Analyse(contents []byte, hints []string) (language string) {
 		return string(classifier.Classes[idx])
 	}
 
+	cutoff := math.Inf(-1)
+	if maxCandidates > 0 && maxCandidates < len(scores) {
+		sorted := append([]float64{}, scores...)
+		sort.Sort(sort.Reverse(sort.Float64Slice(sorted)))
+		cutoff = sorted[maxCandidates-1]
+	}
+
 	langs := map[string]struct{}{}
 	for _, hint := range hints {
 		langs[hint] = struct{}{}
@@ -66,7 +84,7 @@ func Analyse(contents []byte, hints []string) (language string) {
 	for id, score := range scores {
 		answer := string(classifier.Classes[id])
 		if _, ok := langs[answer]; ok {
-			if score >= best_score {
+			if score >= best_score && score >= cutoff {
 				best_score = score
 				best_answer = answer
 			}
@@ -74,3 +92,20 @@ func Analyse(contents []byte, hints []string) (language string) {
 	}
 	return best_answer
 }
+
+// the least difference between the log scores of the best and second best
+// languages for analyseConfident, i.e. the best must be e^20 times as likely
+const minClassifierMargin = 20
+
+// analyseConfident is Analyse without hints, returning the empty string
+// unless the best scoring language clearly beats all others.
+func analyseConfident(contents []byte) (language string) {
+	classifier := getClassifier()
+	scores, idx, _ := classifier.LogScores(tokenizer.Tokenize(contents))
+	for id, score := range scores {
+		if id != idx && scores[idx]-score < minClassifierMargin {
+			return ""
+		}
+	}
+	return string(classifier.Classes[idx])
+}
@@ -0,0 +1,143 @@
+package linguist
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// a line from a gitattributes(5) file
+type attrRule struct {
+	re    *regexp.Regexp
+	attrs map[string]string
+}
+
+// The rules of gitattributes(5) files, of which Scanner understands, following
+// github linguist, "linguist-vendored", "linguist-generated" and
+// "linguist-documentation", which force a file to be ignored (or, when unset,
+// not ignored) for that reason, and "linguist-language", which overrides the
+// detected language, as well as "export-ignore" (see
+// Options.RespectExportIgnore).
+//
+// The zero value has no rules.
+type GitAttributes struct {
+	rules []attrRule
+}
+
+var attrIgnoreReasons = []struct{ attr, reason string }{
+	{"linguist-vendored", IgnoredVendored},
+	{"linguist-documentation", IgnoredDocumentation},
+	{"linguist-generated", IgnoredGenerated},
+}
+
+// Reads the attributes for the directory tree at root, as git(1) does: from
+// the .gitattributes at root, followed by .git/info/attributes, which takes
+// precedence. Either may be missing.
+func ReadGitAttributes(root string) (*GitAttributes, error) {
+	a := &GitAttributes{}
+	for _, name := range []string{".gitattributes", filepath.Join(".git", "info", "attributes")} {
+		data, err := ioutil.ReadFile(filepath.Join(root, name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		a.Load(data)
+	}
+	return a, nil
+}
+
+// Adds the rules of a gitattributes file with the given contents.
+//
+// Files must be loaded in increasing order of precedence, which in git(1) is
+// the in-tree .gitattributes followed by $GIT_DIR/info/attributes. Lines with
+// invalid patterns are skipped.
+func (a *GitAttributes) Load(data []byte) {
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		re, err := compileGlob(fields[0], "")
+		if err != nil {
+			continue
+		}
+		attrs := map[string]string{}
+		for _, attr := range fields[1:] {
+			switch {
+			case strings.HasPrefix(attr, "-"):
+				attrs[attr[1:]] = "false"
+			case strings.HasPrefix(attr, "!"):
+				attrs[attr[1:]] = ""
+			case strings.Contains(attr, "="):
+				kv := strings.SplitN(attr, "=", 2)
+				attrs[kv[0]] = kv[1]
+			default:
+				attrs[attr] = "true"
+			}
+		}
+		a.rules = append(a.rules, attrRule{re, attrs})
+	}
+}
+
+// Returns the attributes set for path, relative to the root of the
+// repository and separated by slashes; unset attributes are "false" and
+// attributes reset with "!" are absent.
+func (a *GitAttributes) For(path string) map[string]string {
+	attrs := map[string]string{}
+	if a == nil {
+		return attrs
+	}
+	for _, rule := range a.rules {
+		if !rule.re.MatchString(path) {
+			continue
+		}
+		for k, v := range rule.attrs {
+			if v == "" {
+				delete(attrs, k)
+			} else {
+				attrs[k] = v
+			}
+		}
+	}
+	return attrs
+}
+
+// Reports whether path is excluded from archives by the export-ignore
+// attribute, which like git-archive(1) also excludes everything below a
+// directory with the attribute, e.g. "tests export-ignore".
+func (a *GitAttributes) ExportIgnored(p string) bool {
+	for dir := p; dir != "." && dir != "/" && dir != ""; dir = path.Dir(dir) {
+		if a.For(dir)["export-ignore"] == "true" {
+			return true
+		}
+	}
+	return false
+}
+
+// attrIgnoreReason applies the linguist-* attributes in attrs to reason, as
+// found by FilenameIgnoreReason or ContentsIgnoreReason, returning the reason
+// the file should be ignored, if any.
+func attrIgnoreReason(reason string, attrs map[string]string, contents bool) string {
+	for _, a := range attrIgnoreReasons {
+		r := a.reason
+		// linguist-generated is only considered alongside contents, so that
+		// UnignoreContents applies to it like the built in detection
+		if (r == IgnoredGenerated) != contents {
+			continue
+		}
+		switch attrs[a.attr] {
+		case "true":
+			return r
+		case "false":
+			if reason == r {
+				reason = ""
+			}
+		}
+	}
+	return reason
+}
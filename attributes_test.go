@@ -0,0 +1,29 @@
+package linguist
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGitAttributes(t *testing.T) {
+	a := &GitAttributes{}
+	a.Load([]byte("# comment\n*.txt linguist-language=Go text\ndocs/** linguist-documentation\ndocs/api/** -linguist-documentation\n"))
+	// as from .git/info/attributes, taking precedence
+	a.Load([]byte("*.txt linguist-language=Python !text\n"))
+
+	for path, want := range map[string]map[string]string{
+		"notes.txt":       {"linguist-language": "Python"},
+		"docs/guide.md":   {"linguist-documentation": "true"},
+		"docs/api/foo.md": {"linguist-documentation": "false"},
+		"main.go":         {},
+	} {
+		if got := a.For(path); !reflect.DeepEqual(got, want) {
+			t.Errorf("For(%q) = %v, want %v", path, got, want)
+		}
+	}
+
+	var none *GitAttributes
+	if got := none.For("notes.txt"); len(got) != 0 {
+		t.Errorf("nil GitAttributes: For = %v, want none", got)
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// bytes of each language in the files last modified by every author, see
+// -by-author
+var author_langs = map[string]map[string]int{}
+
+// putAuthorBytes counts size bytes of the file at path towards language for
+// the author of the last commit modifying it
+func putAuthorBytes(language, path string, size int) {
+	author := "(unknown)"
+	if last, ok := last_commits[path]; ok {
+		author = last.author
+	}
+	if author_langs[author] == nil {
+		author_langs[author] = map[string]int{}
+	}
+	author_langs[author][language] += size
+}
+
+// the languages of one author for -by-author
+type authorResult struct {
+	Author    string      `json:"author"`
+	Size      int         `json:"size"`
+	Languages []*language `json:"languages"`
+}
+
+// writeByAuthor writes the composition of the files last modified by each
+// author, the authors with the most bytes first, with the languages of each
+// folded into Other like the results, see foldOther
+func writeByAuthor() {
+	authors := []*authorResult{}
+	for author, langs := range author_langs {
+		a := &authorResult{Author: author}
+		for _, size := range langs {
+			a.Size += size
+		}
+		for lang, size := range langs {
+			percent := 0.0
+			if a.Size > 0 {
+				percent = float64(size) / float64(a.Size) * 100.0
+			}
+			a.Languages = append(a.Languages, &language{
+				Language:   lang,
+				Percent:    percent,
+				Percentage: fmt.Sprintf("%.2f", percent),
+				Size:       size,
+			})
+		}
+		sort.Sort(sortableResult{a.Languages, sortSize, true})
+		a.Languages = foldOther(a.Languages)
+		authors = append(authors, a)
+	}
+	sort.Slice(authors, func(i, j int) bool {
+		if authors[i].Size != authors[j].Size {
+			return authors[i].Size > authors[j].Size
+		}
+		return authors[i].Author < authors[j].Author
+	})
+
+	if output_json {
+		json_bytes, err := marshalJSON(authors)
+		checkErr(err)
+		fmt.Fprintln(output, string(json_bytes))
+		return
+	}
+	// the same for every author, so that their languages line up
+	width := 0
+	for _, a := range authors {
+		if w := nameWidth(a.Languages); w > width {
+			width = w
+		}
+	}
+	color := useColor()
+	for i, a := range authors {
+		if i > 0 {
+			fmt.Fprintln(output)
+		}
+		fmt.Fprintf(output, "%s (%s)\n", a.Author, humanizeBytes(a.Size))
+		fmtstr := fmt.Sprintf("  %% %ds", width)
+		for _, l := range a.Languages {
+			name := fmt.Sprintf(fmtstr, l.Language)
+			if color {
+				name = colorize(name, languageColor(l.Language))
+			}
+			fmt.Fprintf(output, "%s: %07.4f%%\n", name, l.Percent)
+		}
+	}
+}
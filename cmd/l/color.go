@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/dayvonjersen/linguist"
+)
+
+// isTerminal reports whether f is a character device, e.g. not a pipe or file.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
+
+// useColor decides whether human readable output should contain ANSI escapes:
+// -no-color and -color take precedence, otherwise only when writing directly
+// to a terminal and NO_COLOR is unset.
+func useColor() bool {
+	switch {
+	case output_no_color:
+		return false
+	case output_color:
+		return true
+	case output_file != nil, os.Getenv("NO_COLOR") != "":
+		return false
+	}
+	return isTerminal(os.Stdout)
+}
+
+// shown for groups without a color of their own, as on github
+const groupFallbackColor = "#cccccc"
+
+// languageColor returns the color for a language in the results; with -group
+// this is the color of the group's parent language, falling back to gray.
+func languageColor(language string) string {
+	color := linguist.LanguageColor(language)
+	if output_group && color == "" && language != "Other" {
+		return groupFallbackColor
+	}
+	return color
+}
+
+// formattedColor is languageColor in the notation chosen with -color-format:
+// "#f1e05a" (hex, as in languages.yml), "rgb(241, 224, 90)" (rgb) or
+// "hsl(53, 84%, 65%)" (hsl). Languages without a color have none.
+func formattedColor(language string) string {
+	hex := languageColor(language)
+	r, g, b, ok := parseHexColor(hex)
+	if !ok {
+		return hex
+	}
+	switch output_color_format {
+	case "rgb":
+		return fmt.Sprintf("rgb(%d, %d, %d)", r, g, b)
+	case "hsl":
+		h, s, l := rgbToHSL(r, g, b)
+		return fmt.Sprintf("hsl(%.0f, %.0f%%, %.0f%%)", h, s*100, l*100)
+	}
+	return hex
+}
+
+// rgbToHSL converts a color to hue in degrees, saturation and lightness.
+func rgbToHSL(r, g, b uint8) (h, s, l float64) {
+	rf, gf, bf := float64(r)/255, float64(g)/255, float64(b)/255
+	max := math.Max(rf, math.Max(gf, bf))
+	min := math.Min(rf, math.Min(gf, bf))
+	l = (max + min) / 2
+	if max == min {
+		return 0, 0, l
+	}
+	d := max - min
+	if l > 0.5 {
+		s = d / (2 - max - min)
+	} else {
+		s = d / (max + min)
+	}
+	switch max {
+	case rf:
+		h = (gf - bf) / d
+		if gf < bf {
+			h += 6
+		}
+	case gf:
+		h = (bf-rf)/d + 2
+	default:
+		h = (rf-gf)/d + 4
+	}
+	return h * 60, s, l
+}
+
+// parseHexColor parses colors in the "#123ABC" notation from languages.yml.
+func parseHexColor(hex string) (r, g, b uint8, ok bool) {
+	hex = strings.TrimPrefix(hex, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) != 6 {
+		return 0, 0, 0, false
+	}
+	n, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return 0, 0, 0, false
+	}
+	return uint8(n >> 16), uint8(n >> 8), uint8(n), true
+}
+
+// colorize wraps s in a 24-bit ANSI foreground color escape for hex,
+// or returns s unchanged if hex is not a valid color.
+func colorize(s, hex string) string {
+	r, g, b, ok := parseHexColor(hex)
+	if !ok {
+		return s
+	}
+	return fmt.Sprintf("\x1b[38;2;%d;%d;%dm%s\x1b[0m", r, g, b, s)
+}
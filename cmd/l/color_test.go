@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/dayvonjersen/linguist"
+)
+
+func TestColorOutput(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"main.go": "package main\n"})
+
+	for _, tt := range []struct {
+		args  []string
+		color bool
+	}{
+		// standard output is a pipe here
+		{[]string{"-fs"}, false},
+		{[]string{"-fs", "-color"}, true},
+		{[]string{"-fs", "-color", "-no-color"}, false},
+		{[]string{"-fs", "-color", "-json"}, false},
+	} {
+		out := mustRunL(t, dir, tt.args...)
+		if got := strings.Contains(out, "\x1b["); got != tt.color {
+			t.Errorf("l %s: escapes %v, want %v\n%q", strings.Join(tt.args, " "), got, tt.color, out)
+		}
+	}
+	if want := colorize("Go", "#00ADD8"); !strings.Contains(mustRunL(t, dir, "-fs", "-color"), want) {
+		t.Errorf("l -fs -color: Go not in %q", want)
+	}
+}
+
+// an entry of -json-with-colors
+type colorEntry struct {
+	Language string `json:"language"`
+	Color    string `json:"color"`
+}
+
+func TestGroupColor(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"Makefile.am": "all:\n\techo\n",
+		"refs.bib":    "@article{x,\n  title={x}\n}\n",
+		"main.go":     "package main\n",
+	})
+	out := mustRunL(t, dir, "-fs", "-group", "-json-with-colors")
+	var entries []colorEntry
+	if err := json.Unmarshal([]byte(out), &entries); err != nil {
+		t.Fatalf("%v\n%s", err, out)
+	}
+	want := map[string]string{
+		// Automake and BibTeX, in the colors of their groups
+		"Makefile": linguist.LanguageColor("Makefile"),
+		"TeX":      linguist.LanguageColor("TeX"),
+		"Go":       linguist.LanguageColor("Go"),
+	}
+	if len(entries) != len(want) {
+		t.Errorf("got %v, want %v", entries, want)
+	}
+	for _, e := range entries {
+		if e.Color == "" || e.Color != want[e.Language] {
+			t.Errorf("%s: color %q, want %q", e.Language, e.Color, want[e.Language])
+		}
+	}
+
+	defer func() { output_group = false }()
+	output_group = true
+	if got := languageColor("Text"); got != groupFallbackColor {
+		t.Errorf("languageColor(Text) with -group = %q, want %q", got, groupFallbackColor)
+	}
+}
+
+func TestColorFormat(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"main.go": "package main\n", "app.js": "var x = 1;\n"})
+	for _, tt := range []struct {
+		format string
+		want   map[string]string
+	}{
+		{"", map[string]string{"Go": "#00ADD8", "JavaScript": "#f1e05a"}},
+		{"hex", map[string]string{"Go": "#00ADD8", "JavaScript": "#f1e05a"}},
+		{"rgb", map[string]string{"Go": "rgb(0, 173, 216)", "JavaScript": "rgb(241, 224, 90)"}},
+		{"hsl", map[string]string{"Go": "hsl(192, 100%, 42%)", "JavaScript": "hsl(53, 84%, 65%)"}},
+	} {
+		args := []string{"-fs", "-json-with-colors"}
+		if tt.format != "" {
+			args = append(args, "-color-format", tt.format)
+		}
+		out := mustRunL(t, dir, args...)
+		var entries []colorEntry
+		if err := json.Unmarshal([]byte(out), &entries); err != nil {
+			t.Fatalf("%v: %v\n%s", args, err, out)
+		}
+		for _, e := range entries {
+			if e.Color != tt.want[e.Language] {
+				t.Errorf("-color-format %s: %s is %q, want %q", tt.format, e.Language, e.Color, tt.want[e.Language])
+			}
+		}
+	}
+	if out, err := runL(t, dir, "-fs", "-json-with-colors", "-color-format", "cmyk"); err == nil {
+		t.Errorf("-color-format cmyk: got\n%s\nwant an error", out)
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"io/ioutil"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// a number of goroutines given with a flag, or "auto" for effectiveCPUs, as
+// is any number less than 1, see cpuThreads
+type threadsFlag struct {
+	n *int
+}
+
+func (t threadsFlag) String() string {
+	if t.n == nil {
+		return ""
+	}
+	if *t.n < 1 {
+		return "auto"
+	}
+	return strconv.Itoa(*t.n)
+}
+
+func (t threadsFlag) Set(v string) error {
+	if v == "auto" {
+		*t.n = effectiveCPUs()
+		return nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return err
+	}
+	*t.n = n
+	return nil
+}
+
+// cpuThreads returns the number of goroutines to use for a threadsFlag of n
+func cpuThreads(n int) int {
+	if n < 1 {
+		return effectiveCPUs()
+	}
+	return n
+}
+
+// effectiveCPUs returns the number of CPUs this process may use: GOMAXPROCS,
+// which defaults to the number of CPUs, unless a cgroup CPU quota (e.g. the
+// CPU limit of a container) allows fewer.
+func effectiveCPUs() int {
+	n := runtime.GOMAXPROCS(0)
+	if quota := cgroupCPUQuota(); quota > 0 && quota < n {
+		n = quota
+	}
+	return n
+}
+
+// cgroupCPUQuota returns the CPU quota of the cgroup of this process,
+// rounded up to whole CPUs, or 0 if there is none or it could not be read.
+//
+// Only the root of the cgroup filesystems is read, which is the process's own
+// cgroup within containers, for cgroup v2 and then v1.
+func cgroupCPUQuota() int {
+	// "$MAX $PERIOD", with a $MAX of "max" if unlimited
+	if data, err := ioutil.ReadFile("/sys/fs/cgroup/cpu.max"); err == nil {
+		if fields := strings.Fields(string(data)); len(fields) == 2 {
+			return cpuQuota(fields[0], fields[1])
+		}
+		return 0
+	}
+	quota, err := ioutil.ReadFile("/sys/fs/cgroup/cpu/cpu.cfs_quota_us")
+	if err != nil {
+		return 0
+	}
+	period, err := ioutil.ReadFile("/sys/fs/cgroup/cpu/cpu.cfs_period_us")
+	if err != nil {
+		return 0
+	}
+	return cpuQuota(strings.TrimSpace(string(quota)), strings.TrimSpace(string(period)))
+}
+
+// cpuQuota returns quota/period rounded up, or 0 if either isn't a positive
+// number, e.g. "max" or -1 for no quota
+func cpuQuota(quota, period string) int {
+	q, err := strconv.Atoi(quota)
+	if err != nil || q <= 0 {
+		return 0
+	}
+	p, err := strconv.Atoi(period)
+	if err != nil || p <= 0 {
+		return 0
+	}
+	return (q + p - 1) / p
+}
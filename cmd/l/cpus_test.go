@@ -0,0 +1,67 @@
+package main
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestThreadsAuto(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(1))
+	var n int
+	if err := (threadsFlag{&n}).Set("auto"); err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("auto with GOMAXPROCS 1: %d threads, want 1", n)
+	}
+
+	runtime.GOMAXPROCS(3)
+	if err := (threadsFlag{&n}).Set("auto"); err != nil {
+		t.Fatal(err)
+	}
+	// a cgroup quota may allow fewer
+	want := 3
+	if quota := cgroupCPUQuota(); quota > 0 && quota < want {
+		want = quota
+	}
+	if n != want {
+		t.Errorf("auto with GOMAXPROCS 3: %d threads, want %d", n, want)
+	}
+
+	if err := (threadsFlag{&n}).Set("8"); err != nil || n != 8 {
+		t.Errorf("Set(8): %d, %v", n, err)
+	}
+	if err := (threadsFlag{&n}).Set("many"); err == nil {
+		t.Error("Set(many): no error")
+	}
+
+	// the default
+	n = 0
+	if got := (threadsFlag{&n}).String(); got != "auto" {
+		t.Errorf("String() of 0 = %q, want auto", got)
+	}
+	if got := cpuThreads(n); got != effectiveCPUs() {
+		t.Errorf("cpuThreads(0) = %d, want %d", got, effectiveCPUs())
+	}
+	if got := cpuThreads(2); got != 2 {
+		t.Errorf("cpuThreads(2) = %d, want 2", got)
+	}
+}
+
+func TestCPUQuota(t *testing.T) {
+	for _, tt := range []struct {
+		quota, period string
+		want          int
+	}{
+		{"200000", "100000", 2},
+		{"150000", "100000", 2},
+		{"50000", "100000", 1},
+		{"max", "100000", 0},
+		{"-1", "100000", 0},
+		{"100000", "0", 0},
+	} {
+		if got := cpuQuota(tt.quota, tt.period); got != tt.want {
+			t.Errorf("cpuQuota(%s, %s) = %d, want %d", tt.quota, tt.period, got, tt.want)
+		}
+	}
+}
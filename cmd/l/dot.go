@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// bytes of each language in every directory and its subdirectories, see -dot
+var dir_langs = map[string]map[string]int{}
+
+// putDirBytes counts size bytes of the file at path towards language in every
+// directory containing it, up to the root "."
+func putDirBytes(language, path string, size int) {
+	dir := path
+	for dir != "." {
+		dir = filepath.Dir(dir)
+		if dir_langs[dir] == nil {
+			dir_langs[dir] = map[string]int{}
+		}
+		dir_langs[dir][language] += size
+	}
+}
+
+// dominantLanguage returns the language with the most bytes in langs, ties are
+// broken by name
+func dominantLanguage(langs map[string]int) string {
+	dominant := ""
+	for language, size := range langs {
+		if size > langs[dominant] || (size == langs[dominant] && language < dominant) {
+			dominant = language
+		}
+	}
+	return dominant
+}
+
+// dotQuote quotes lines as a DOT string, separated by "\n" escapes
+func dotQuote(lines ...string) string {
+	for i, s := range lines {
+		s = strings.Replace(s, `\`, `\\`, -1)
+		lines[i] = strings.Replace(s, `"`, `\"`, -1)
+	}
+	return `"` + strings.Join(lines, `\n`) + `"`
+}
+
+// writeDot writes a Graphviz graph of the directory tree for -dot, with one
+// node per directory labeled with its dominant language, filled in that
+// language's color, and an edge from each directory to its subdirectories.
+func writeDot() {
+	dirs := []string{}
+	for dir := range dir_langs {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	fmt.Fprintln(output, "digraph languages {")
+	fmt.Fprintln(output, "\tnode [shape=box, style=filled, fillcolor=\"#ffffff\"];")
+	for _, dir := range dirs {
+		language := dominantLanguage(dir_langs[dir])
+		label := filepath.Base(dir)
+		if output_hash_paths {
+			label = displayPath(dir)
+		}
+		attrs := fmt.Sprintf("label=%s", dotQuote(label, language))
+		if color := languageColor(language); color != "" {
+			attrs += fmt.Sprintf(", fillcolor=%s", dotQuote(color))
+		}
+		fmt.Fprintf(output, "\t%s [%s];\n", dotQuote(displayPath(dir)), attrs)
+	}
+	for _, dir := range dirs {
+		if dir != "." {
+			fmt.Fprintf(output, "\t%s -> %s;\n", dotQuote(displayPath(filepath.Dir(dir))), dotQuote(displayPath(dir)))
+		}
+	}
+	fmt.Fprintln(output, "}")
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var (
+	dotNode = regexp.MustCompile(`^\t("(?:[^"\\]|\\.)*") \[label=("(?:[^"\\]|\\.)*")(?:, fillcolor="#[0-9a-fA-F]{6}")?\];$`)
+	dotEdge = regexp.MustCompile(`^\t("(?:[^"\\]|\\.)*") -> ("(?:[^"\\]|\\.)*");$`)
+)
+
+func TestDot(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"main.go":          "package main\n\nfunc main() {}\n",
+		"web/app.js":       "var x = 1;\n",
+		"web/css/site.css": "p { color: red; }\n",
+		"tools/gen.py":     "x = 1\n",
+		"tools/more.py":    "y = 2\n",
+		"vendor/lib.rb":    "puts 1\n",
+	})
+	out := mustRunL(t, dir, "-fs", "-dot")
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	if lines[0] != "digraph languages {" || lines[len(lines)-1] != "}" {
+		t.Fatalf("-dot: got\n%s\nwant a digraph", out)
+	}
+
+	labels := map[string]string{}
+	edges := 0
+	for _, line := range lines[2 : len(lines)-1] {
+		if m := dotNode.FindStringSubmatch(line); m != nil {
+			labels[m[1]] = m[2]
+		} else if m := dotEdge.FindStringSubmatch(line); m != nil {
+			if labels[m[1]] == "" || labels[m[2]] == "" {
+				t.Errorf("edge %s between undeclared nodes", line)
+			}
+			edges++
+		} else {
+			t.Errorf("-dot: unexpected line %q", line)
+		}
+	}
+	// one node per directory with files counted, vendor/ is not
+	want := map[string]string{
+		`"."`:       `".\nGo"`,
+		`"web"`:     `"web\nCSS"`,
+		`"web/css"`: `"css\nCSS"`,
+		`"tools"`:   `"tools\nPython"`,
+	}
+	if len(labels) != len(want) || edges != len(want)-1 {
+		t.Errorf("-dot: got %d nodes and %d edges, want %d and %d:\n%s", len(labels), edges, len(want), len(want)-1, out)
+	}
+	for node, label := range want {
+		if labels[node] != label {
+			t.Errorf("node %s: label %s, want %s", node, labels[node], label)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// fingerprint returns a hash of the composition of results, see
+// -fingerprint: the languages and their percentages rounded to whole
+// numbers, sorted by name, so that it only changes along with the output.
+func fingerprint(results []*language) string {
+	lines := []string{}
+	for _, l := range results {
+		lines = append(lines, fmt.Sprintf("%s\t%d\n", l.Language, int(math.Round(l.Percent))))
+	}
+	sort.Strings(lines)
+	h := sha256.New()
+	for _, line := range lines {
+		h.Write([]byte(line))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// writeFingerprint writes the fingerprint of results, as an object with
+// -json
+func writeFingerprint(results []*language) {
+	if output_json {
+		json_bytes, err := marshalJSON(map[string]string{"fingerprint": fingerprint(results)})
+		checkErr(err)
+		fmt.Fprintln(output, string(json_bytes))
+		return
+	}
+	fmt.Fprintln(output, fingerprint(results))
+}
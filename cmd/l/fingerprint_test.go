@@ -0,0 +1,38 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestFingerprint(t *testing.T) {
+	a := []*language{{Language: "Go", Percent: 74.6}, {Language: "YAML", Percent: 25.4}}
+	b := []*language{{Language: "YAML", Percent: 25.2}, {Language: "Go", Percent: 74.8}}
+	if fingerprint(a) != fingerprint(b) {
+		t.Errorf("fingerprint depends on order or unrounded percentages")
+	}
+	c := []*language{{Language: "Go", Percent: 73.4}, {Language: "YAML", Percent: 26.6}}
+	if fingerprint(a) == fingerprint(c) {
+		t.Errorf("fingerprint of a different composition is the same")
+	}
+
+	dir := t.TempDir()
+	files := map[string]string{"util.py": "x = 1\n"}
+	for _, name := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		files[name+".go"] = "package main\n"
+		files[name+".rb"] = "puts 1\n"
+	}
+	writeFiles(t, dir, files)
+	out := mustRunL(t, dir, "-fs", "-fingerprint", "-threads-cpu", "4")
+	if !regexp.MustCompile(`^[0-9a-f]{64}\n$`).MatchString(out) {
+		t.Fatalf("-fingerprint: got %q, want a SHA-256 hash", out)
+	}
+	for i := 0; i < 3; i++ {
+		if again := mustRunL(t, dir, "-fs", "-fingerprint", "-threads-cpu", "4"); again != out {
+			t.Errorf("-fingerprint: got %q, then %q", out, again)
+		}
+	}
+	if again := mustRunL(t, dir, "-fs", "-fingerprint", "-sort", "name"); again != out {
+		t.Errorf("-fingerprint -sort name: got %q, want %q", again, out)
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIgnoreFile(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		".ignore":         "generated/\n",
+		"main.go":         "package main\n",
+		"generated/x.go":  "package x\n",
+		"sub/.rgignore":   "*.py\n",
+		"sub/keep.go":     "package sub\n",
+		"sub/skip.py":     "x = 1\n",
+		"other/counts.py": "x = 1\n",
+	})
+	results := runJSON(t, dir, "-fs")
+	if results["Go"].Files != 3 || results["Python"].Files != 2 {
+		t.Errorf("-fs: got %v, want 3 Go and 2 Python files", results)
+	}
+	results = runJSON(t, dir, "-fs", "-ignore-file", ".ignore", "-ignore-file", ".rgignore")
+	if results["Go"].Files != 2 || results["Python"].Files != 1 {
+		t.Errorf("-ignore-file .ignore -ignore-file .rgignore: got %v, want 2 Go and 1 Python files", results)
+	}
+}
+
+func TestNoGitignore(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		".git/HEAD":    "ref: refs/heads/main\n",
+		".gitignore":   "*.py\nbuild/\n",
+		"main.go":      "package main\n",
+		"util.py":      "x = 1\n",
+		"build/out.rb": "puts 1\n",
+	})
+	if results := runJSON(t, dir, "-fs"); len(results) != 1 || results["Go"] == nil {
+		t.Errorf("-fs: got %v, want Go only", results)
+	}
+	if results := runJSON(t, dir, "-fs", "-no-gitignore"); len(results) != 3 {
+		t.Errorf("-fs -no-gitignore: got %v, want Go, Python and Ruby", results)
+	}
+}
+
+func TestDotfiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		".env":              "PORT=8080\n",
+		"config/.env.local": "PORT=8081\n",
+		".envrc":            "export PATH=$PWD/bin:$PATH\n",
+		".editorconfig":     "root = true\n",
+	})
+	results := runJSON(t, dir, "-fs")
+	for language, files := range map[string]int{"Dotenv": 2, "Shell": 1, "EditorConfig": 1} {
+		if results[language] == nil || results[language].Files != files {
+			t.Errorf("%s: got %v, want %d files", language, results[language], files)
+		}
+	}
+}
+
+func TestMaxDepth(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"main.go":          "package main\n",
+		"lib/util.py":      "x = 1\n",
+		"lib/deep/lib.rb":  "puts 1\n",
+		"lib/deep/er/x.js": "var x;\n",
+		"vendor/deep/x.rb": "puts 1\n",
+	})
+	for _, tt := range []struct {
+		depth   string
+		files   int
+		ignored int
+	}{
+		{"1", 1, 0},
+		{"2", 2, 0},
+		{"3", 3, 1},
+		{"0", 4, 1},
+	} {
+		out := mustRunL(t, dir, "-fs", "-max-depth", tt.depth, "-totals")
+		var totals map[string]float64
+		if err := json.Unmarshal([]byte(out), &totals); err != nil {
+			t.Fatalf("-max-depth %s: %v\n%s", tt.depth, err, out)
+		}
+		if totals["total_files"] != float64(tt.files) || totals["ignored_paths"] != float64(tt.ignored) {
+			t.Errorf("-max-depth %s: got %v, want %d files and %d ignored", tt.depth, totals, tt.files, tt.ignored)
+		}
+	}
+}
+
+func TestEditorDotfiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		".vimrc":             "set number\n",
+		"vim/plugin/x.vim":   "let g:x = 1\n",
+		".emacs.d/init.el":   "(setq x 1)\n",
+		".emacs.d/lisp/y.el": "(provide 'y)\n",
+	})
+	results := runJSON(t, dir, "-fs")
+	if len(results) != 2 || results["Vim Script"].Files != 2 || results["Emacs Lisp"].Files != 2 {
+		t.Errorf("got %v, want 2 Vim Script and 2 Emacs Lisp files", results)
+	}
+	// .vimrc is not an extension
+	if out := mustRunL(t, dir, "-fs"); !strings.Contains(out, "4 files (47 B, 2 extensions)") {
+		t.Errorf("got\n%s\nwant .vim and .el only", out)
+	}
+}
+
+func TestUnreadable(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("permissions do not apply to root")
+	}
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"main.go":       "package main\n",
+		"secret.py":     "x = 1\n",
+		"locked/lib.rb": "puts 1\n",
+	})
+	for _, name := range []string{"secret.py", "locked"} {
+		if err := os.Chmod(filepath.Join(dir, name), 0); err != nil {
+			t.Fatal(err)
+		}
+		defer os.Chmod(filepath.Join(dir, name), 0755)
+	}
+	out := mustRunL(t, dir, "-fs")
+	if !strings.Contains(out, "2 unreadable paths, skipped\n") || strings.Contains(out, "Python") {
+		t.Errorf("got\n%s\nwant secret.py and locked skipped", out)
+	}
+	results := runJSON(t, dir, "-fs")
+	if len(results) != 1 || results["Go"] == nil {
+		t.Errorf("-json: got %v, want only Go", results)
+	}
+}
@@ -1,14 +1,21 @@
 package main
 
 import (
+	"fmt"
+	"io/ioutil"
 	"log"
+	"path"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/dayvonjersen/git4go"
 	"github.com/dayvonjersen/linguist"
 )
 
-func processTree(repo *git4go.Repository, odb *git4go.Odb, tree_id *git4go.Oid, parent []string) {
+// lookupTree returns the tree for tree_id, which may also be a commit
+func lookupTree(repo *git4go.Repository, tree_id *git4go.Oid) *git4go.Tree {
 	var tree *git4go.Tree
 	var commit *git4go.Commit
 	commit, err := repo.LookupCommit(tree_id)
@@ -28,66 +35,281 @@ func processTree(repo *git4go.Repository, odb *git4go.Odb, tree_id *git4go.Oid,
 		tree, err = commit.Tree()
 		checkErr(err)
 	}
+	return tree
+}
+
+// resolveTreeish returns the id of the object name refers to, see -git-tree
+// and -since.
+//
+// name is looked up as a reference first, then as the full or abbreviated id
+// of a commit or tree, and may be followed by ancestry suffixes as in
+// gitrevisions(7), e.g. HEAD~1, main^2 or v1.0~2^2, and by ^{tree},
+// ^{commit} or ^{}, e.g. HEAD^{tree} for the tree of HEAD.
+func resolveTreeish(repo *git4go.Repository, name string) *git4go.Oid {
+	base, suffix := name, ""
+	if i := strings.IndexAny(name, "~^"); i > 0 {
+		base, suffix = name[:i], name[i:]
+	}
+	oid := resolveName(repo, base)
+	if suffix == "" {
+		return oid
+	}
+	odb, err := repo.Odb()
+	checkErr(err)
+	for suffix != "" {
+		op := suffix[0]
+		suffix = suffix[1:]
+		if op == '^' && strings.HasPrefix(suffix, "{") {
+			end := strings.IndexByte(suffix, '}')
+			if end < 0 {
+				checkErr(fmt.Errorf("%s: unsupported revision syntax %q", name, "^"+suffix))
+			}
+			oid = peelObject(odb, name, oid, suffix[1:end])
+			suffix = suffix[end+1:]
+			continue
+		}
+		n, digits := 1, 0
+		for digits < len(suffix) && suffix[digits] >= '0' && suffix[digits] <= '9' {
+			digits++
+		}
+		if digits > 0 {
+			n, err = strconv.Atoi(suffix[:digits])
+			checkErr(err)
+			suffix = suffix[digits:]
+		}
+		if op != '~' && op != '^' {
+			checkErr(fmt.Errorf("%s: unsupported revision syntax %q", name, string(op)+suffix))
+		}
+		if op == '~' {
+			// the first parent, n times
+			for ; n > 0; n-- {
+				if oid = nthParent(odb, oid, 1); oid == nil {
+					checkErr(fmt.Errorf("%s: no such ancestor", name))
+				}
+			}
+		} else if n > 0 {
+			if oid = nthParent(odb, oid, n); oid == nil {
+				checkErr(fmt.Errorf("%s: no such parent", name))
+			}
+		}
+	}
+	log.Println(name, "resolved to", oid)
+	return oid
+}
+
+// peelObject returns the id of the object of type kind that oid, named name,
+// refers to, for ^{kind} in resolveTreeish: a commit itself or its tree for
+// "tree", a commit for "commit", and the object itself for "" as tags are
+// already resolved.
+func peelObject(odb *git4go.Odb, name string, oid *git4go.Oid, kind string) *git4go.Oid {
+	obj, err := odb.Read(oid)
+	checkErr(err)
+	switch {
+	case kind == "" || kind == obj.Type.String():
+		return oid
+	case kind == "tree" && obj.Type == git4go.ObjectCommit:
+		// the tree is the first header of a commit
+		line := strings.SplitN(string(obj.Data), "\n", 2)[0]
+		tree, err := git4go.NewOid(strings.TrimPrefix(line, "tree "))
+		checkErr(err)
+		return tree
+	case kind == "tree" || kind == "commit":
+		checkErr(fmt.Errorf("%s: %s is a %s, not a %s", name, oid, obj.Type, kind))
+	}
+	checkErr(fmt.Errorf("%s: unsupported revision syntax %q", name, "^{"+kind+"}"))
+	return nil
+}
+
+// resolveName returns the id of the object name refers to, looked up as a
+// reference first, then as the full or abbreviated id of a commit or tree.
+func resolveName(repo *git4go.Repository, name string) *git4go.Oid {
+	ref, err := repo.DwimReference(name)
+	if err != nil {
+		if oid := lookupSHA(repo, name); oid != nil {
+			log.Println(name, "is not a reference, using object", oid)
+			return oid
+		}
+	}
+	checkErr(err)
+	resolved, err := ref.Resolve()
+	checkErr(err)
+	return resolved.Target()
+}
+
+// recordCommit notes the commit or tree oid which name resolved to, see -git-tree,
+// for -record-commit
+func recordCommit(repo *git4go.Repository, name string, oid *git4go.Oid) {
+	scanned_commit = &commitInfo{SHA: oid.String()}
+	if ref, err := repo.DwimReference(name); err == nil {
+		if resolved, err := ref.Resolve(); err == nil {
+			scanned_commit.Ref = resolved.Name()
+		}
+	}
+	if commit, err := repo.LookupCommit(oid); err == nil {
+		scanned_commit.AuthorDate = commit.Author().When.Format(time.RFC3339)
+	}
+	log.Printf("scanning %+v\n", *scanned_commit)
+}
+
+// lookupSHA returns the id of the commit or tree whose id starts with the
+// (at least 4) hex digits in sha, or nil if there is none.
+func lookupSHA(repo *git4go.Repository, sha string) *git4go.Oid {
+	oid := resolveSHA(repo, sha)
+	if oid == nil {
+		return nil
+	}
+	obj, err := repo.Lookup(oid)
+	if err != nil {
+		log.Println("looking up", sha, "as an object id:", err)
+		return nil
+	}
+	if t := obj.Type(); t != git4go.ObjectCommit && t != git4go.ObjectTree {
+		return nil
+	}
+	return oid
+}
+
+// resolveSHA returns the id of the object of any type whose id starts with
+// the (at least 4) hex digits in sha, or nil if there is none or several.
+func resolveSHA(repo *git4go.Repository, sha string) *git4go.Oid {
+	if len(sha) < 4 || len(sha) > 40 {
+		return nil
+	}
+	sha = strings.ToLower(sha)
+	oid, err := git4go.NewOid(sha)
+	if err == nil {
+		return oid
+	}
+	// abbreviated, git4go can't look up prefixes in packfiles so all
+	// objects are searched for a unique match
+	odb, err := repo.Odb()
+	checkErr(err)
+	all, err := odb.GetAllObjects()
+	checkErr(err)
+	for _, id := range all {
+		if !strings.HasPrefix(id.String(), sha) {
+			continue
+		}
+		if oid != nil {
+			log.Println(sha, "is ambiguous")
+			return nil
+		}
+		oid = id
+	}
+	return oid
+}
+
+// classifyBlob writes the language of the blob whose id starts with sha,
+// see -blob, using -filename for the strategies based on the name of a file.
+// With -json, everything known about the blob is written, see
+// linguist.FileInfo.
+func classifyBlob(repo *git4go.Repository, odb *git4go.Odb, sha string) {
+	oid := resolveSHA(repo, sha)
+	if oid == nil {
+		checkErr(fmt.Errorf("-blob %s: no such object", sha))
+	}
+	obj, err := odb.Read(oid)
+	checkErr(err)
+	if obj.Type != git4go.ObjectBlob {
+		checkErr(fmt.Errorf("-blob %s: %s is a %s, not a blob", sha, oid, obj.Type))
+	}
+	fi := detector.Analyze(input_filename, obj.Data)
+	log.Printf("%s as %q: %+v\n", oid, input_filename, fi)
+	if output_json {
+		json_bytes, err := marshalJSON(fi)
+		checkErr(err)
+		fmt.Fprintln(output, string(json_bytes))
+		return
+	}
+	if fi.Language == "" {
+		fmt.Fprintln(output, "(unknown)")
+		return
+	}
+	fmt.Fprintln(output, fi.Language)
+}
+
+// treeAttributes returns the attributes from the .gitattributes in tree_id,
+// if any, followed by $GIT_DIR/info/attributes, so that local overrides take
+// precedence as in git(1)
+func treeAttributes(repo *git4go.Repository, odb *git4go.Odb, tree_id *git4go.Oid) *linguist.GitAttributes {
+	attributes := &linguist.GitAttributes{}
+	if entry := lookupTree(repo, tree_id).EntryByName(".gitattributes"); entry != nil {
+		obj, err := odb.Read(entry.Id)
+		checkErr(err)
+		log.Println("reading attributes from .gitattributes")
+		attributes.Load(obj.Data)
+	}
+	info := filepath.Join(".git", "info", "attributes")
+	if fileExists(info) {
+		data, err := ioutil.ReadFile(info)
+		checkErr(err)
+		log.Println("reading attributes from", info)
+		attributes.Load(data)
+	}
+	return attributes
+}
+
+// walkTree returns a linguist.WalkFunc over the blobs in tree_id and its
+// subtrees, skipping git submodules.
+//
+// If since is not nil, entries identical to those at the same path in since
+// are skipped, so that only added or modified blobs are counted (see -since)
+func walkTree(repo *git4go.Repository, odb *git4go.Odb, tree_id *git4go.Oid, since *git4go.Tree) linguist.WalkFunc {
+	return func(visit func(linguist.Entry) error) error {
+		return visitTree(repo, odb, tree_id, []string{}, since, visit)
+	}
+}
+
+// visitTree passes every entry in tree_id to visit, and those in its
+// subtrees unless visit returns filepath.SkipDir for them, see walkTree
+func visitTree(repo *git4go.Repository, odb *git4go.Odb, tree_id *git4go.Oid, parent []string, since *git4go.Tree, visit func(linguist.Entry) error) error {
+	tree := lookupTree(repo, tree_id)
 	for _, entry := range tree.Entries {
 		//fmode := fmt.Sprintf("%06o", int(entry.Filemode))
 		ftype := entry.Type.String()
 		fhash := entry.Id.String()
 		fname := entry.Name
+		fpath := path.Join(append(parent, fname)...)
+
+		var since_entry *git4go.TreeEntry
+		if since != nil {
+			since_entry = since.EntryByName(fname)
+			if since_entry != nil && since_entry.Id.Equal(entry.Id) {
+				log.Println(fpath, "unchanged since", input_git_since, "skipping")
+				continue
+			}
+		}
 
 		switch ftype {
 		case "tree":
+			err := visit(linguist.Entry{Path: fpath, IsDir: true})
+			if err == filepath.SkipDir {
+				continue
+			} else if err != nil {
+				return err
+			}
 			log.Println("entering subtree", fname)
 			oid, err := git4go.NewOid(fhash)
 			checkErr(err)
-			processTree(repo, odb, oid, append(parent, fname))
+			var since_tree *git4go.Tree
+			if since_entry != nil && since_entry.Type == git4go.ObjectTree {
+				since_tree = lookupTree(repo, since_entry.Id)
+			}
+			if err := visitTree(repo, odb, oid, append(parent, fname), since_tree, visit); err != nil {
+				return err
+			}
 		case "blob":
-			fname = filepath.Join(append(parent, fname)...)
-
 			oid, err := git4go.NewOid(fhash)
 			checkErr(err)
 			obj, err := odb.Read(oid)
 			checkErr(err)
 
-			size := len(obj.Data)
-
-			log.Println(fname, "is", size, "bytes")
-			if size == 0 {
-				log.Println(fname, "is empty file, skipping")
-				continue
-			}
-
-			if !unignore_filenames && linguist.ShouldIgnoreFilename(fname) {
-				log.Println(fname, ": filename should be ignored, skipping")
-				ignored_paths++
-				continue
-			}
-
-			by_name := linguist.LanguageByFilename(fname)
-			if by_name != "" {
-				log.Println(fname, "got result by name: ", by_name)
-				putResult(by_name, size)
-				continue
-			}
-
-			contents := obj.Data
-
-			if !unignore_contents && linguist.ShouldIgnoreContents(contents) {
-				log.Println(fname, ": contents should be ignored, skipping")
-				ignored_paths++
-				continue
-			}
-
-			hints := linguist.LanguageHints(fname)
-			log.Printf("%s got language hints: %#v\n", fname, hints)
-			by_data := linguist.LanguageByContents(contents, hints)
-
-			if by_data != "" {
-				log.Println(fname, "got result by data: ", by_data)
-				putResult(by_data, size)
-				continue
+			err = visit(linguist.Entry{Path: fpath, Size: len(obj.Data), Read: func(full bool) ([]byte, error) {
+				return obj.Data, nil
+			}})
+			if err != nil {
+				return err
 			}
-			log.Println(fname, "got no result!!")
-			putResult("(unknown)", size)
 		case "commit":
 			log.Println(fname, "is a git submodule (ftype == \"commit\"), skipping")
 			continue
@@ -95,4 +317,5 @@ func processTree(repo *git4go.Repository, odb *git4go.Odb, tree_id *git4go.Oid,
 			println("currently unsupported ftype:" + ftype)
 		}
 	}
+	return nil
 }
@@ -0,0 +1,339 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dayvonjersen/git4go"
+)
+
+// gitFixture is a repository made with git(1) in a temporary directory
+type gitFixture struct {
+	t   *testing.T
+	dir string
+
+	// the author and committer of commits, and their date
+	name, date string
+}
+
+func newGitFixture(t *testing.T) *gitFixture {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	g := &gitFixture{t, t.TempDir(), "test", "2020-01-01T00:00:00Z"}
+	g.git("init", "-q")
+	return g
+}
+
+// git runs git(1) in the fixture, returning its output without the trailing newline
+func (g *gitFixture) git(args ...string) string {
+	g.t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = g.dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME="+g.name, "GIT_AUTHOR_EMAIL="+g.name+"@example.com", "GIT_AUTHOR_DATE="+g.date,
+		"GIT_COMMITTER_NAME="+g.name, "GIT_COMMITTER_EMAIL="+g.name+"@example.com", "GIT_COMMITTER_DATE="+g.date,
+	)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		g.t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// commit writes files, given by path and contents, and commits them
+func (g *gitFixture) commit(message string, files map[string]string) string {
+	g.t.Helper()
+	writeFiles(g.t, g.dir, files)
+	g.git("add", "-A")
+	g.git("commit", "-q", "-m", message)
+	return g.git("rev-parse", "HEAD")
+}
+
+func (g *gitFixture) open() *git4go.Repository {
+	g.t.Helper()
+	repo, err := git4go.OpenRepository(g.dir)
+	if err != nil {
+		g.t.Fatal(err)
+	}
+	return repo
+}
+
+func TestResolveTreeish(t *testing.T) {
+	g := newGitFixture(t)
+	first := g.commit("first", map[string]string{"main.go": "package main\n"})
+	second := g.commit("second", map[string]string{"util.py": "x = 1\n"})
+	g.git("checkout", "-q", "-b", "feature", first)
+	side := g.commit("side", map[string]string{"lib.rb": "puts 1\n"})
+	g.git("checkout", "-q", "-")
+	g.git("merge", "-q", "--no-edit", "feature")
+	merge := g.git("rev-parse", "HEAD")
+	repo := g.open()
+
+	for name, want := range map[string]string{
+		"HEAD":      merge,
+		"feature":   side,
+		"feature~1": first,
+		"HEAD~1":    second,
+		"HEAD^":     second,
+		"HEAD^1":    second,
+		"HEAD^2":    side,
+		"HEAD~2":    first,
+		"HEAD^2~1":  first,
+		"HEAD^0":    merge,
+		second[:7]:  second,
+
+		"HEAD^{commit}":  merge,
+		"HEAD^{}":        merge,
+		"HEAD~1^{tree}":  g.git("rev-parse", "HEAD~1^{tree}"),
+		"HEAD^{tree}":    g.git("rev-parse", "HEAD^{tree}"),
+		"feature^{tree}": g.git("rev-parse", "feature^{tree}"),
+	} {
+		if got := resolveTreeish(repo, name).String(); got != want {
+			t.Errorf("resolveTreeish(%q) = %s, want %s", name, got, want)
+		}
+	}
+
+	if results := runJSON(t, g.dir, "-git-tree", "HEAD^{tree}"); len(results) != 3 {
+		t.Errorf("-git-tree HEAD^{tree}: got %v, want Go, Python and Ruby", results)
+	}
+	for _, treeish := range []string{"HEAD^{blob}", "HEAD^{tree}^{commit}", "HEAD^{tree"} {
+		if out, err := runL(t, g.dir, "-git-tree", treeish); err == nil {
+			t.Errorf("-git-tree %s: got\n%s\nwant an error", treeish, out)
+		}
+	}
+}
+
+func TestSince(t *testing.T) {
+	g := newGitFixture(t)
+	first := g.commit("first", map[string]string{"main.go": "package main\n", "lib/lib.rb": "puts 1\n"})
+	g.commit("second", map[string]string{"util.py": "x = 1\n", "lib/lib.rb": "puts 2\n"})
+
+	results := runJSON(t, g.dir, "-git")
+	if len(results) != 3 {
+		t.Errorf("-git: got %v, want Go, Python and Ruby", results)
+	}
+	for _, since := range []string{"HEAD~1", "HEAD^", first} {
+		results := runJSON(t, g.dir, "-git", "-since", since)
+		if len(results) != 2 || results["Python"] == nil || results["Ruby"] == nil {
+			t.Errorf("-since %s: got %v, want only the added Python and modified Ruby", since, results)
+		}
+	}
+	if results := runJSON(t, g.dir, "-git", "-since", "HEAD"); len(results) != 0 {
+		t.Errorf("-since HEAD: got %v, want nothing", results)
+	}
+}
+
+func TestInfoAttributes(t *testing.T) {
+	g := newGitFixture(t)
+	g.commit("first", map[string]string{
+		".gitattributes": "*.txt linguist-language=Go\nvendor/** -linguist-vendored\n",
+		"notes.txt":      "x = 1\n",
+		"vendor/lib.rb":  "puts 1\n",
+	})
+	for _, mode := range []string{"-git", "-fs"} {
+		if results := runJSON(t, g.dir, mode); results["Go"] == nil || results["Ruby"] == nil {
+			t.Errorf("%s with .gitattributes: got %v, want Go and Ruby", mode, results)
+		}
+	}
+
+	writeFiles(t, g.dir, map[string]string{".git/info/attributes": "*.txt linguist-language=Python\n"})
+	for _, mode := range []string{"-git", "-fs"} {
+		if results := runJSON(t, g.dir, mode); results["Python"] == nil || results["Go"] != nil {
+			t.Errorf("%s with .git/info/attributes: got %v, want Python rather than Go", mode, results)
+		}
+	}
+}
+
+func TestRecencyWeighted(t *testing.T) {
+	g := newGitFixture(t)
+	g.date = "2018-01-01T00:00:00Z"
+	g.commit("legacy", map[string]string{"legacy.rb": strings.Repeat("puts 1\n", 100)})
+	g.date = "2020-01-01T00:00:00Z"
+	g.commit("active", map[string]string{"main.go": strings.Repeat("package main\n", 20)})
+
+	if results := runJSON(t, g.dir, "-git"); len(results) != 2 || results["Ruby"].Size <= results["Go"].Size {
+		t.Errorf("-git: got %v, want Ruby above Go", results)
+	}
+	// two years is about four half-lives, so Ruby counts for about a sixteenth
+	results := runJSON(t, g.dir, "-recency-weighted")
+	if len(results) != 2 || results["Go"].Size != 260 || results["Ruby"].Size >= results["Go"].Size {
+		t.Errorf("-recency-weighted: got %v, want Go above Ruby", results)
+	}
+}
+
+func TestGitTreeSHA(t *testing.T) {
+	g := newGitFixture(t)
+	first := g.commit("first", map[string]string{"main.go": "package main\n", "lib/lib.rb": "puts 1\n"})
+	g.commit("second", map[string]string{"util.py": "x = 1\n"})
+	tree := g.git("rev-parse", first+"^{tree}")
+	subtree := g.git("rev-parse", first+":lib")
+
+	for _, tt := range []struct {
+		treeish string
+		want    []string
+	}{
+		{first, []string{"Go", "Ruby"}},
+		{first[:7], []string{"Go", "Ruby"}},
+		{strings.ToUpper(first[:10]), []string{"Go", "Ruby"}},
+		{tree, []string{"Go", "Ruby"}},
+		{tree[:8], []string{"Go", "Ruby"}},
+		{subtree, []string{"Ruby"}},
+		{"HEAD", []string{"Go", "Python", "Ruby"}},
+	} {
+		results := runJSON(t, g.dir, "-git-tree", tt.treeish)
+		if len(results) != len(tt.want) {
+			t.Errorf("-git-tree %s: got %v, want %v", tt.treeish, results, tt.want)
+			continue
+		}
+		for _, language := range tt.want {
+			if results[language] == nil {
+				t.Errorf("-git-tree %s: got %v, want %v", tt.treeish, results, tt.want)
+			}
+		}
+	}
+
+	// without a commit there is no history
+	for _, flag_name := range []string{"-recency-weighted", "-by-author"} {
+		out, err := runL(t, g.dir, flag_name, "-git-tree", tree)
+		if err == nil || !strings.Contains(out, flag_name+" needs the history of a commit") {
+			t.Errorf("%s -git-tree %s: got %v\n%s\nwant an error asking for a commit", flag_name, tree, err, out)
+		}
+	}
+
+	// blobs are not trees
+	blob := g.git("rev-parse", first+":main.go")
+	if out, err := runL(t, g.dir, "-git-tree", blob); err == nil {
+		t.Errorf("-git-tree of a blob: got\n%s\nwant an error", out)
+	}
+}
+
+func TestByAuthor(t *testing.T) {
+	g := newGitFixture(t)
+	g.name = "ann"
+	g.commit("first", map[string]string{"main.go": "package main\n", "util.py": "x = 1\n"})
+	g.name = "bob"
+	g.commit("second", map[string]string{"util.py": "x = 2\ny = 3\n", "lib.rb": "puts 1\n"})
+
+	out := mustRunL(t, g.dir, "-by-author", "-json")
+	var got []*authorResult
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("-by-author -json: %v\n%s", err, out)
+	}
+	// the last author of util.py is bob
+	want := []struct {
+		author string
+		size   int
+		langs  []string
+	}{
+		{"bob <bob@example.com>", 19, []string{"Python", "Ruby"}},
+		{"ann <ann@example.com>", 13, []string{"Go"}},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("-by-author: got\n%s\nwant %v", out, want)
+	}
+	for i, a := range got {
+		langs := []string{}
+		for _, l := range a.Languages {
+			langs = append(langs, l.Language)
+		}
+		if a.Author != want[i].author || a.Size != want[i].size || !reflect.DeepEqual(langs, want[i].langs) {
+			t.Errorf("author %d: got %s with %d bytes of %v, want %v", i, a.Author, a.Size, langs, want[i])
+		}
+	}
+}
+
+func TestBlob(t *testing.T) {
+	g := newGitFixture(t)
+	head := g.commit("first", map[string]string{
+		"main.go":    "package main\n",
+		"bin/deploy": "#!/usr/bin/env python\nprint(1)\n",
+	})
+	blob := g.git("rev-parse", "HEAD:main.go")
+
+	for _, tt := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"-blob", blob, "-filename", "main.go"}, "Go"},
+		{[]string{"-blob", blob[:7], "-filename", "main.go"}, "Go"},
+		// by contents only
+		{[]string{"-blob", g.git("rev-parse", "HEAD:bin/deploy")}, "Python"},
+	} {
+		if out := mustRunL(t, g.dir, tt.args...); out != tt.want+"\n" {
+			t.Errorf("%v: got %q, want %s", tt.args, out, tt.want)
+		}
+	}
+
+	out := mustRunL(t, g.dir, "-blob", blob, "-filename", "vendor/x.go", "-json")
+	var fi struct {
+		Path       string `json:"path"`
+		Language   string `json:"language"`
+		Size       int    `json:"size"`
+		IsVendored bool   `json:"is_vendored"`
+	}
+	if err := json.Unmarshal([]byte(out), &fi); err != nil || fi.Path != "vendor/x.go" || fi.Language != "Go" || fi.Size != 13 || !fi.IsVendored {
+		t.Errorf("-blob -json: got %+v, %v\n%s", fi, err, out)
+	}
+
+	if out, err := runL(t, g.dir, "-blob", head); err == nil {
+		t.Errorf("-blob of a commit: got %q, want an error", out)
+	}
+}
+
+func TestRespectExportIgnore(t *testing.T) {
+	g := newGitFixture(t)
+	g.commit("first", map[string]string{
+		".gitattributes":     "tools/ export-ignore\n*.rb export-ignore\nkeep.rb -export-ignore\n",
+		"main.go":            "package main\n",
+		"tools/gen/gen.py":   "x = 1\n",
+		"scripts/release.rb": "puts 1\n",
+		"keep.rb":            "puts 2\n",
+	})
+	for _, mode := range []string{"-git", "-fs"} {
+		if results := runJSON(t, g.dir, mode); len(results) != 3 || results["Ruby"].Files != 2 {
+			t.Errorf("%s: got %v, want everything", mode, results)
+		}
+		results := runJSON(t, g.dir, mode, "-respect-export-ignore")
+		if len(results) != 2 || results["Go"] == nil || results["Ruby"].Files != 1 {
+			t.Errorf("%s -respect-export-ignore: got %v, want only main.go and keep.rb", mode, results)
+		}
+	}
+}
+
+func TestRecordCommit(t *testing.T) {
+	g := newGitFixture(t)
+	first := g.commit("first", map[string]string{"main.go": "package main\n"})
+	g.date = "2021-06-01T12:00:00Z"
+	head := g.commit("second", map[string]string{"util.py": "x = 1\n"})
+	branch := g.git("symbolic-ref", "HEAD")
+
+	for _, tt := range []struct {
+		args []string
+		want commitInfo
+	}{
+		{nil, commitInfo{head, "2021-06-01T12:00:00Z", branch}},
+		{[]string{"-git-tree", first}, commitInfo{first, "2020-01-01T00:00:00Z", ""}},
+	} {
+		args := append([]string{"-json", "-record-commit"}, tt.args...)
+		out := mustRunL(t, g.dir, args...)
+		var report struct {
+			Commit  *commitInfo          `json:"commit"`
+			Results map[string]*language `json:"results"`
+		}
+		if err := json.Unmarshal([]byte(out), &report); err != nil {
+			t.Fatalf("%v: %v\n%s", args, err, out)
+		}
+		if report.Commit == nil || *report.Commit != tt.want {
+			t.Errorf("%v: got commit %+v, want %+v", args, report.Commit, tt.want)
+		}
+		if report.Results["Go"] == nil {
+			t.Errorf("%v: got results %v, want Go", args, report.Results)
+		}
+	}
+}
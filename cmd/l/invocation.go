@@ -0,0 +1,65 @@
+package main
+
+import "os"
+
+// how l was run, recorded in JSON output with -record-invocation
+type invocation struct {
+	Args []string `json:"args"` // os.Args, as given, including the program name
+	Dir  string   `json:"dir"`  // the working directory l was started in
+}
+
+// the commit scanned in git mode, recorded in JSON output with -record-commit
+type commitInfo struct {
+	SHA        string `json:"sha"`                   // of the commit, or the tree given to -git-tree
+	AuthorDate string `json:"author_date,omitempty"` // RFC 3339, unless a tree was given
+	Ref        string `json:"ref,omitempty"`         // e.g. refs/heads/master, if a reference was given
+}
+
+var (
+	// the invocation of this run, set by recordInvocation
+	invoked invocation
+
+	// the commit scanned, set by recordCommit in git mode
+	scanned_commit *commitInfo
+)
+
+// recordInvocation notes the arguments and working directory, which must be
+// done before findGitDir() changes the latter
+func recordInvocation() {
+	dir, err := os.Getwd()
+	checkErr(err)
+	invoked = invocation{os.Args, dir}
+}
+
+// the metadata of a report, see withMetadata
+type metadata struct {
+	Invocation *invocation `json:"invocation,omitempty"`
+	Commit     *commitInfo `json:"commit,omitempty"`
+}
+
+// recordedMetadata returns what -record-invocation and -record-commit ask to
+// be included in JSON output, and whether there is anything
+func recordedMetadata() (meta metadata, ok bool) {
+	if output_invocation {
+		meta.Invocation = &invoked
+	}
+	if output_commit {
+		meta.Commit = scanned_commit
+	}
+	return meta, meta.Invocation != nil || meta.Commit != nil
+}
+
+// withMetadata returns results as they should be marshalled for -json:
+// unchanged, or with -record-invocation or -record-commit wrapped alongside
+// the metadata as {"invocation": {...}, "commit": {...}, "results": ...},
+// which -merge accepts as well
+func withMetadata(results interface{}) interface{} {
+	meta, ok := recordedMetadata()
+	if !ok {
+		return results
+	}
+	return struct {
+		metadata
+		Results interface{} `json:"results"`
+	}{meta, results}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRecordInvocation(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"main.go": "package main\n"})
+	args := []string{"-json", "-fs", "-record-invocation", "-limit", "1"}
+	out := mustRunL(t, dir, args...)
+	var report struct {
+		Invocation invocation           `json:"invocation"`
+		Results    map[string]*language `json:"results"`
+	}
+	if err := json.Unmarshal([]byte(out), &report); err != nil {
+		t.Fatalf("-record-invocation: %v\n%s", err, out)
+	}
+	if want := append([]string{os.Args[0]}, args...); !reflect.DeepEqual(report.Invocation.Args, want) {
+		t.Errorf("args: got %q, want %q", report.Invocation.Args, want)
+	}
+	want, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := filepath.EvalSymlinks(report.Invocation.Dir); err != nil || got != want {
+		t.Errorf("dir: got %q, want %q", report.Invocation.Dir, dir)
+	}
+	if l := report.Results["Go"]; l == nil || l.Files != 1 {
+		t.Errorf("results: got %v, want Go", report.Results)
+	}
+
+	// and the report can still be merged
+	path := filepath.Join(t.TempDir(), "report.json")
+	if err := os.WriteFile(path, []byte(out), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if l := runJSON(t, dir, "-merge", path)["Go"]; l == nil || l.Files != 1 {
+		t.Errorf("-merge of a recorded report: got %+v, want Go", l)
+	}
+}
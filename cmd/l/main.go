@@ -7,7 +7,11 @@ import (
 	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
+	"regexp"
 	"sort"
+	"strings"
+	"time"
 
 	"github.com/dayvonjersen/git4go"
 	"github.com/dayvonjersen/linguist"
@@ -15,6 +19,9 @@ import (
 
 func checkErr(err error) {
 	if err != nil {
+		if output_file != nil {
+			output_file.Abort()
+		}
 		if output_debug {
 			log.Panicln(err)
 		} else {
@@ -29,14 +36,82 @@ var (
 	input_mode_git          bool
 	input_mode_fs           bool
 	input_git_tree          string
+	input_git_since         string
+	input_blob              string
+	input_filename          string
+	input_recency_weighted  bool
+	output_by_author        bool
 	output_json             bool
 	output_json_with_colors bool
+	output_json_compact     bool
+	output_path             string
+	output_color            bool
+	output_no_color         bool
 	output_limit            int
+	output_min_bytes        int
+	output_no_other         bool
+	output_verbose_other    bool
+	output_examples         int
+	output_split_embedded   bool
+	output_frontmatter      bool
+	output_list_files       bool
+	output_report_eol       bool
+	output_dot              bool
+	output_markdown         bool
+	output_markdown_base    string
+	output_totals           bool
+	output_merge            bool
+	output_fingerprint      bool
+	output_stats_json       bool
+	output_invocation       bool
+	output_commit           bool
+	output_no_footer        bool
+	output_summary_split    bool
+	output_hash_paths       bool
+	output_ndjson           bool
+	output_template         string
+	output_sort             string
+	output_color_format     string
+	output_percent_base     string
+	output_cap_file_size    int
+	output_group            bool
+	output_types            string
 	output_debug            bool
+	input_rules             string
+	input_cpuprofile        string
+	input_memprofile        string
+	input_threads_io        int
+	input_threads_cpu       int
+	input_read_buffer       int
+	input_classify_buffer   int
+	input_content_priority  bool
+	input_ignore_files      stringList
+	input_vendor_patterns   stringList
+	input_export_ignore     bool
+	input_exclude_tests     bool
+	input_test_patterns     stringList
+	input_no_gitignore      bool
+	input_skip_empty        bool
+	input_max_depth         int
+	input_max_files         int
+	input_file_timeout      time.Duration
 	unignore_filenames      bool
 	unignore_contents       bool
+	input_count_generated   bool
 )
 
+// a flag which may be given more than once
+type stringList []string
+
+func (s *stringList) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringList) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
 // write a function to create a map with string keys
 func makeMap(results []*language) map[string]*language {
 	m := make(map[string]*language)
@@ -53,45 +128,249 @@ type (
 		Percent  float64 `json:"percent"`
 		Percentage string `json:"percentage"`
 		Size int `json:"size"`
+		Files int `json:"files"`
+		Examples []string `json:"examples,omitempty"`
+
+		// the languages folded into "Other", see -verbose-other
+		Languages []*language `json:"languages,omitempty"`
+	}
+
+	// see -ndjson
+	fileResult struct {
+		Path     string `json:"path"`
+		Language string `json:"language"`
+		Size     int    `json:"size"`
+	}
+
+	// see -totals
+	totals struct {
+		TotalSize      int  `json:"total_size"`
+		TotalFiles     int  `json:"total_files"`
+		TotalLanguages int  `json:"total_languages"`
+		IgnoredPaths   int  `json:"ignored_paths"`
+		Unreadable     int  `json:"unreadable_paths,omitempty"`
+		Sampled        bool `json:"sampled,omitempty"` // see -max-files
 	}
 
 	language_color struct {
-		Language string  `json:"language"`
-		Percent  float64 `json:"percent"`
-		Color    string  `json:"color"`
+		Language string   `json:"language"`
+		Percent  float64  `json:"percent"`
+		Color    string   `json:"color"`
+		Examples []string `json:"examples,omitempty"`
 	}
 )
 
-type sortableResult []*language
+// sorts results by key, one of the sort* constants, ties broken by name
+type sortableResult struct {
+	results []*language
+	key     string
+	desc    bool
+}
+
+// keys for sortableResult, see -sort
+const (
+	sortSize  = "size"
+	sortName  = "name"
+	sortFiles = "files"
+)
 
 func (s sortableResult) Len() int {
-	return len(s)
+	return len(s.results)
 }
 
 func (s sortableResult) Less(i, j int) bool {
-	return s[i].Percent < s[j].Percent
+	a, b := s.results[i], s.results[j]
+	if s.desc {
+		a, b = b, a
+	}
+	switch {
+	case s.key == sortSize && a.Size != b.Size:
+		return a.Size < b.Size
+	case s.key == sortFiles && a.Files != b.Files:
+		return a.Files < b.Files
+	}
+	return s.results[i].Language < s.results[j].Language
 }
 
 func (s sortableResult) Swap(i, j int) {
-	s[i], s[j] = s[j], s[i]
+	s.results[i], s.results[j] = s.results[j], s.results[i]
+}
+
+// sortResults sorts results in the order given with -sort: by size,
+// largest first (desc) or last (asc), by name, or by number of files, most
+// first
+func sortResults(results []*language) {
+	s := sortableResult{results, sortSize, true}
+	switch output_sort {
+	case "asc":
+		s.desc = false
+	case "name":
+		s.key, s.desc = sortName, false
+	case "files":
+		s.key = sortFiles
+	}
+	sort.Sort(s)
 }
 
 var (
-	langs         map[string]int = make(map[string]int)
-	total_size    int            = 0
-	num_files     int            = 0
-	max_len       int            = 0
-	ignored_paths int            = 0
+	ignored_size int = 0 // of the ignored files, see -percent-base
+
+	// the largest file counted for each language, for the footer
+	largest map[string]largestFile = make(map[string]largestFile)
+
+	// the first -examples paths counted for each language
+	examples map[string][]string = make(map[string][]string)
+
+	// the number of files counted using each kind of line ending, see -report-eol
+	eol_counts map[string]int = make(map[string]int)
+
+	// the distinct extensions of the files counted, for the footer
+	extensions map[string]bool = make(map[string]bool)
 )
 
-func putResult(language string, size int) {
-	langs[language] += size
-	total_size += size
-	num_files++
-	if len(language) > max_len {
-		max_len = len(language)
+type largestFile struct {
+	path string
+	size int
+}
+
+// putLanguage notes the file at path, of which size bytes were counted
+// towards language out of whole bytes before -cap-file-size, for the
+// examples, the largest files, -dot and -by-author
+func putLanguage(language, path string, size, whole int) {
+	if output_group {
+		language = linguist.LanguageGroup(language)
+	}
+	if len(examples[language]) < output_examples {
+		examples[language] = append(examples[language], displayPath(path))
+	}
+	if whole > largest[language].size {
+		largest[language] = largestFile{path, whole}
+	}
+	if output_dot {
+		putDirBytes(language, path, size)
+	}
+	if output_by_author {
+		putAuthorBytes(language, path, size)
+	}
+}
+
+func marshalJSON(v interface{}) ([]byte, error) {
+	if output_json_compact {
+		return json.Marshal(v)
+	}
+	return json.MarshalIndent(v, "", "  ")
+}
+
+// humanizeBytes formats n using binary prefixes, e.g. "1.5 KiB"
+func humanizeBytes(n int) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := unit, 0
+	for m := n / unit; m >= unit && exp < 3; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGT"[exp])
+}
+
+// footer summarizes r, e.g.
+// "2 languages detected in 5 files (1.2 KiB, 3 extensions)", where num_langs
+// is the number shown, including Other
+func footer(r linguist.Result, num_langs int) string {
+	if output_merge {
+		// reports don't record their extensions
+		return fmt.Sprintf("%d language%s detected in %d file%s (%s)",
+			num_langs, pluralize(num_langs), r.TotalFiles, pluralize(r.TotalFiles),
+			humanizeBytes(r.TotalSize))
 	}
+	return fmt.Sprintf("%d language%s detected in %d file%s (%s, %d extension%s)",
+		num_langs, pluralize(num_langs), r.TotalFiles, pluralize(r.TotalFiles),
+		humanizeBytes(r.TotalSize), len(extensions), pluralize(len(extensions)))
 }
+
+// foldOther folds the languages of results, largest first, smaller than
+// -min-bytes into "Other" first, then any beyond -limit of those remaining,
+// and returns the rest sorted by -sort, followed by Other unless -no-other.
+// Other from reports given to -merge is always folded into it.
+func foldOther(results []*language) []*language {
+	other := &language{
+		Language: "Other",
+	}
+	folded := 0
+	kept := []*language{}
+	for _, l := range results {
+		if l.Language == "Other" || (output_min_bytes > 0 && l.Size < output_min_bytes) || (output_limit > 0 && len(kept) >= output_limit) {
+			other.Percent += l.Percent
+			other.Size += l.Size
+			other.Files += l.Files
+			if output_verbose_other && l.Language != "Other" {
+				other.Languages = append(other.Languages, l)
+			}
+			folded++
+			continue
+		}
+		kept = append(kept, l)
+	}
+	sortResults(kept)
+	if folded > 0 && !output_no_other {
+		other.Percentage = fmt.Sprintf("%.2f", other.Percent)
+		kept = append(kept, other)
+	}
+	return kept
+}
+
+// groupResult returns r with the languages of each group counted as one, see
+// -group
+func groupResult(r linguist.Result) linguist.Result {
+	sizes, files := map[string]int{}, map[string]int{}
+	for _, stat := range r.Languages {
+		group := linguist.LanguageGroup(stat.Name)
+		sizes[group] += stat.Size
+		files[group] += stat.Files
+	}
+	grouped := linguist.NewResult(sizes, files, r.IgnoredPaths)
+	grouped.Unreadable, grouped.Sampled = r.Unreadable, r.Sampled
+	return grouped
+}
+
+// nameWidth returns the width to pad the names of results to, in text output
+func nameWidth(results []*language) int {
+	width := 0
+	for _, l := range results {
+		if len(l.Language) > width {
+			width = len(l.Language)
+		}
+	}
+	return width
+}
+
+// totalsOf returns the totals for -totals and -template
+func totalsOf(r linguist.Result) totals {
+	return totals{r.TotalSize, r.TotalFiles, len(r.Languages), r.IgnoredPaths, r.Unreadable, r.Sampled}
+}
+
+// splitTypes returns the types given to -type
+func splitTypes(types string) []string {
+	var split []string
+	for _, t := range strings.Split(types, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			split = append(split, t)
+		}
+	}
+	return split
+}
+
+func hasString(list []string, s string) bool {
+	for _, t := range list {
+		if t == s {
+			return true
+		}
+	}
+	return false
+}
+
 func pluralize(num int) string {
 	if num == 1 {
 		return ""
@@ -99,12 +378,113 @@ func pluralize(num int) string {
 	return "s"
 }
 
+// scan finds and classifies the files in git or fs mode, see -git and -fs,
+// and returns the result
+func scan() linguist.Result {
+	var (
+		default_input_mode_git bool
+		default_input_mode_fs  bool
+	)
+
+	if !input_mode_fs && findGitDir() { // side-effect: cd's to GIT_DIR!
+		default_input_mode_git = true
+		default_input_mode_fs = false
+	} else {
+		default_input_mode_git = false
+		default_input_mode_fs = true
+	}
+
+	if !input_mode_git && !input_mode_fs {
+		input_mode_git = default_input_mode_git
+		input_mode_fs = default_input_mode_fs
+	}
+
+	if !input_mode_git && (input_git_tree != "HEAD" || input_git_since != "" || input_blob != "" || input_recency_weighted || output_by_author) {
+		input_mode_git = true
+		input_mode_fs = false
+	}
+
+	if input_mode_git && input_mode_fs {
+		fmt.Println("Please choose one of -git or -fs as flags, but not both.")
+		fmt.Println("You can omit the flags to get the default behavior,")
+		fmt.Printf("which for the current directory is %s\n", func() string {
+			switch {
+			case default_input_mode_git:
+				return "git"
+			case default_input_mode_fs:
+				return "fs"
+			}
+			return "undefined"
+		}())
+		os.Exit(1)
+	}
+
+	openOutput(output_path)
+
+	var scanner *linguist.Scanner
+	if input_mode_fs {
+		scanner = linguist.NewScanner(".", fsOptions())
+	}
+
+	if input_mode_git {
+		repo, err := git4go.OpenRepository(".")
+		checkErr(err)
+		tree_id := resolveTreeish(repo, input_git_tree)
+		if output_commit {
+			recordCommit(repo, input_git_tree, tree_id)
+		}
+		var since *git4go.Tree
+		if input_git_since != "" {
+			since = lookupTree(repo, resolveTreeish(repo, input_git_since))
+		}
+		odb, err := repo.Odb()
+		checkErr(err)
+		if input_blob != "" {
+			classifyBlob(repo, odb, input_blob)
+			closeOutput()
+			os.Exit(0)
+		}
+		if input_recency_weighted || output_by_author {
+			if _, err := repo.LookupCommit(tree_id); err != nil {
+				flag_name := "-recency-weighted"
+				if output_by_author {
+					flag_name = "-by-author"
+				}
+				checkErr(fmt.Errorf("%s needs the history of a commit, but -git-tree %s is a tree", flag_name, input_git_tree))
+			}
+			loadLastModified(repo, odb, tree_id)
+		}
+		options := linguist.Options{
+			Walk:       walkTree(repo, odb, tree_id, since),
+			Attributes: treeAttributes(repo, odb, tree_id),
+		}
+		if input_recency_weighted {
+			options.Weight = recencyWeight
+		}
+		scanner = linguist.NewScanner(".", scanOptions(options))
+	}
+
+	result, err := scanner.Scan()
+	checkErr(err)
+	return result
+}
+
 func main() {
 	flag.BoolVar(
 		&output_debug,
 		"debug", false,
 		"Print debug information.",
 	)
+	flag.StringVar(
+		&input_cpuprofile,
+		"cpuprofile", "",
+		"Write a CPU profile of the scan to file, for go tool pprof.",
+	)
+	flag.StringVar(
+		&input_memprofile,
+		"memprofile", "",
+		"Write a heap profile to file once the scan is done, for go tool pprof.",
+	)
 	flag.BoolVar(
 		&input_mode_git,
 		"git", false,
@@ -115,11 +495,201 @@ func main() {
 		"fs", false,
 		"Scan for files using filesystem.",
 	)
+	flag.Var(
+		&input_ignore_files,
+		"ignore-file",
+		"Also read gitignore patterns from files with this name, e.g. .ignore (can be repeated). Only used with -fs.",
+	)
+	flag.Var(
+		&input_vendor_patterns,
+		"vendor-pattern",
+		"Also treat paths matching this regular expression as vendored, e.g. ^third_party/ (can be repeated).",
+	)
+	flag.BoolVar(
+		&input_exclude_tests,
+		"exclude-tests", false,
+		"Skip test files, such as foo_test.go, test_foo.py, foo.spec.ts, FooTest.java and anything in spec/, counting them as ignored.",
+	)
+	flag.Var(
+		&input_test_patterns,
+		"test-pattern",
+		"With -exclude-tests, treat paths matching this regular expression as test files, instead of the built in conventions (can be repeated).",
+	)
+	flag.IntVar(
+		&input_max_depth,
+		"max-depth", 0,
+		"Only descend n directories deep, counting files in the root as depth 1 (0 for no limit). Deeper paths are not visited at all.",
+	)
+	flag.BoolVar(
+		&input_no_gitignore,
+		"no-gitignore", false,
+		"Do NOT skip paths matched by .gitignore. Only used with -fs.",
+	)
+	flag.BoolVar(
+		&input_export_ignore,
+		"respect-export-ignore", false,
+		"Skip paths with the export-ignore attribute, which are left out of archives made by git archive.",
+	)
+	flag.BoolVar(
+		&input_count_generated,
+		"count-generated", false,
+		"Do NOT skip generated files, such as lockfiles and minified scripts, while still skipping binary files.",
+	)
+	flag.BoolVar(
+		&input_skip_empty,
+		"skip-empty", false,
+		"Count empty files as ignored paths. Empty files never count towards any language or the number of files detected.",
+	)
 	flag.StringVar(
 		&input_git_tree,
 		"git-tree", "HEAD",
 		"tree-ish root to scan. See also man git(1).",
 	)
+	flag.StringVar(
+		&input_git_since,
+		"since", "",
+		"Only scan files added or modified since tree-ish. Implies -git.",
+	)
+	flag.StringVar(
+		&input_blob,
+		"blob", "",
+		"Only classify the blob with this full or abbreviated id, writing its language. Implies -git.",
+	)
+	flag.StringVar(
+		&input_filename,
+		"filename", "",
+		"The name of the -blob, e.g. main.go, for detection by filename or extension. Only used with -blob.",
+	)
+	flag.BoolVar(
+		&input_recency_weighted,
+		"recency-weighted", false,
+		"EXPERIMENTAL: weight each file's size by how recently it was modified, halving every 180 days. Implies -git.",
+	)
+	flag.BoolVar(
+		&output_by_author,
+		"by-author", false,
+		"Output the languages of the files last modified by each author. Implies -git.",
+	)
+	flag.StringVar(
+		&input_rules,
+		"rules", "",
+		"YAML file of content rules (pattern and language) tried in order before any other detection.",
+	)
+	flag.BoolVar(
+		&input_content_priority,
+		"content-priority", false,
+		"Check contents for certain kinds of data, such as CSV, before filenames and extensions.",
+	)
+	flag.IntVar(
+		&input_threads_io,
+		"threads-io", 1,
+		"Read files using n goroutines. Only used with -fs, as git objects are read while walking the tree.",
+	)
+	flag.Var(
+		threadsFlag{&input_threads_cpu},
+		"threads-cpu",
+		"Classify files using n goroutines, or \"auto\" (or 0) for as many as CPUs may be used, respecting GOMAXPROCS and container CPU limits.",
+	)
+	flag.IntVar(
+		&input_read_buffer,
+		"read-buffer", 64,
+		"Queue up to n files found by walking to be read.",
+	)
+	flag.IntVar(
+		&input_classify_buffer,
+		"classify-buffer", 64,
+		"Queue up to n files which were read to be classified.",
+	)
+	flag.BoolVar(
+		&output_report_eol,
+		"report-eol", false,
+		"Summarize how many files use LF, CRLF, CR or mixed line endings, after the results.",
+	)
+	flag.StringVar(
+		&output_sort,
+		"sort", "desc",
+		"Order languages by size, largest first (desc) or last (asc), by name (name) or by number of files (files).",
+	)
+	flag.StringVar(
+		&output_percent_base,
+		"percent-base", "counted",
+		"Compute percentages over the bytes counted (counted) or over all the bytes scanned, including ignored files (scanned).",
+	)
+	flag.StringVar(
+		&output_template,
+		"template", "",
+		"Render the results with a Go text/template, e.g. '{{range .Languages}}{{.Language}} {{.Percentage}}{{\"\\n\"}}{{end}}'.",
+	)
+	flag.BoolVar(
+		&output_ndjson,
+		"ndjson", false,
+		"Output a JSON object per line for each file as it is classified, instead of the results.",
+	)
+	flag.BoolVar(
+		&output_hash_paths,
+		"hash-paths", false,
+		"Replace every path in the output, e.g. with -examples, by a stable hash of it.",
+	)
+	flag.BoolVar(
+		&output_totals,
+		"totals", false,
+		"Output only the total size, number of files and languages, and ignored paths as JSON.",
+	)
+	flag.BoolVar(
+		&output_merge,
+		"merge", false,
+		"Instead of scanning, combine the reports written with -json given as arguments, e.g. l -merge a.json b.json.",
+	)
+	flag.BoolVar(
+		&output_invocation,
+		"record-invocation", false,
+		"With -json, include the command line arguments and working directory, as {\"invocation\": ..., \"results\": ...}.",
+	)
+	flag.BoolVar(
+		&output_commit,
+		"record-commit", false,
+		"With -json in git mode, include the SHA, author date and reference of the commit scanned, as {\"commit\": ..., \"results\": ...}.",
+	)
+	flag.BoolVar(
+		&output_stats_json,
+		"stats-json", false,
+		"Output results as JSON for web frontends: {\"languages\": {name: {size, percent, color, type}}, \"meta\": totals}.",
+	)
+	flag.BoolVar(
+		&output_fingerprint,
+		"fingerprint", false,
+		"Output only a hash of the languages and their percentages rounded to whole numbers, which changes along with the composition.",
+	)
+	flag.BoolVar(
+		&output_no_footer,
+		"no-footer", false,
+		"Omit the summary lines below the results, such as the number of languages detected and ignored paths.",
+	)
+	flag.BoolVar(
+		&output_summary_split,
+		"summary-split", false,
+		"Output only the bytes and files of first-party code versus vendored and generated third-party code, and their ratio.",
+	)
+	flag.BoolVar(
+		&output_markdown,
+		"markdown", false,
+		"Output results as a markdown table, e.g. for a pull request comment.",
+	)
+	flag.StringVar(
+		&output_markdown_base,
+		"markdown-base", "",
+		"With -markdown, add a column with the change in percentage of each language since this report written with -json, e.g. for the base branch.",
+	)
+	flag.BoolVar(
+		&output_dot,
+		"dot", false,
+		"Output a Graphviz DOT graph of the directory tree, labeling each directory with its dominant language.",
+	)
+	flag.BoolVar(
+		&output_list_files,
+		"list-files", false,
+		"Only print the path of every file which would be classified, without reading or classifying contents.",
+	)
 	flag.BoolVar(
 		&output_json,
 		"json", false,
@@ -130,107 +700,256 @@ func main() {
 		"json-with-colors", false,
 		"Output results in JSON format, including any HTML color codes defined for associated languages.",
 	)
+	flag.StringVar(
+		&output_color_format,
+		"color-format", "hex",
+		"Write colors with -json-with-colors and -template as hex (#f1e05a), rgb (rgb(241, 224, 90)) or hsl (hsl(53, 84%, 65%)).",
+	)
+	flag.BoolVar(
+		&output_json_compact,
+		"json-compact", false,
+		"Output results in JSON format on a single line, rather than indented. Can be combined with -json-with-colors.",
+	)
+	flag.StringVar(
+		&output_path,
+		"o", "",
+		"Write results to file instead of stdout. The file is replaced atomically once all output has been written.",
+	)
+	flag.BoolVar(
+		&output_color,
+		"color", false,
+		"Always show language names in their associated colors, even if not writing to a terminal.",
+	)
+	flag.BoolVar(
+		&output_no_color,
+		"no-color", false,
+		"Never show language names in their associated colors.",
+	)
 	flag.IntVar(
 		&output_limit,
 		"limit", 10,
 		"Limit number of languages to n results. n <= 0 for unlimited.",
 	)
+	flag.IntVar(
+		&output_min_bytes,
+		"min-bytes", 0,
+		"Fold languages with fewer than n bytes in total into Other, before applying -limit. n <= 0 to include all (default).",
+	)
+	flag.BoolVar(
+		&output_no_other,
+		"no-other", false,
+		"Drop languages excluded by -limit or -min-bytes, rather than folding them into Other.",
+	)
+	flag.BoolVar(
+		&output_verbose_other,
+		"verbose-other", false,
+		"List the languages folded into Other, with their sizes, under its \"languages\" in JSON output.",
+	)
+	flag.IntVar(
+		&output_examples,
+		"examples", 0,
+		"Include the paths of up to n files counted for each language in JSON output.",
+	)
+	flag.BoolVar(
+		&output_split_embedded,
+		"split-embedded", false,
+		"Count the contents of <script> and <style> elements in HTML files as JavaScript and CSS (approximate).",
+	)
+	flag.BoolVar(
+		&output_frontmatter,
+		"split-frontmatter", false,
+		"Count the front matter of Markdown and other prose or markup files as YAML (or TOML, for +++ fences).",
+	)
+	flag.IntVar(
+		&input_max_files,
+		"max-files", 0,
+		"Stop scanning after n files have been classified, reporting the composition of that sample. n <= 0 for no limit (default).",
+	)
+	flag.DurationVar(
+		&input_file_timeout,
+		"file-timeout", 0,
+		"Give up detecting the language of a file after this long, e.g. 5s, counting it as (unknown). 0 for no timeout (default).",
+	)
+	flag.IntVar(
+		&output_cap_file_size,
+		"cap-file-size", 0,
+		"Count at most n bytes of any single file towards its language. n <= 0 for no cap (default).",
+	)
+	flag.BoolVar(
+		&output_group,
+		"group", false,
+		"Count languages as the parent language they are grouped under, e.g. fish as Shell.",
+	)
+	flag.StringVar(
+		&output_types,
+		"type", "",
+		"Only count languages of these comma separated types: programming, markup, data or prose.",
+	)
 	flag.BoolVar(
 		&unignore_filenames,
 		"unignore-filenames", false,
-		"Do NOT skip processing ignored file types based on filename (NOT RECOMMENDED)",
+		"Do NOT skip vendored files and documentation, based on filename (NOT RECOMMENDED)",
 	)
 	flag.BoolVar(
 		&unignore_contents,
 		"unignore-contents", false,
-		"Do NOT skip processing ignored file types based on contents (NOT RECOMMENDED)",
+		"Do NOT skip binary and generated files, based on contents (NOT RECOMMENDED)",
 	)
 
 	flag.Parse()
+	recordInvocation()
+
+	output_json = output_json || output_json_with_colors || output_json_compact
 
-	output_json = output_json || output_json_with_colors
+	switch output_sort {
+	case "desc", "asc", "name", "files":
+	default:
+		fmt.Fprintf(os.Stderr, "invalid -sort %q, expected one of desc, asc, name or files\n", output_sort)
+		os.Exit(1)
+	}
+	for _, t := range splitTypes(output_types) {
+		switch t {
+		case "programming", "markup", "data", "prose":
+		default:
+			fmt.Fprintf(os.Stderr, "invalid -type %q, expected programming, markup, data or prose\n", t)
+			os.Exit(1)
+		}
+	}
+	switch output_color_format {
+	case "hex", "rgb", "hsl":
+	default:
+		fmt.Fprintf(os.Stderr, "invalid -color-format %q, expected hex, rgb or hsl\n", output_color_format)
+		os.Exit(1)
+	}
+	switch output_percent_base {
+	case "counted", "scanned":
+	default:
+		fmt.Fprintf(os.Stderr, "invalid -percent-base %q, expected counted or scanned\n", output_percent_base)
+		os.Exit(1)
+	}
+	if output_template != "" {
+		parseTemplate(output_template)
+	}
 
 	if !output_debug {
 		log.SetOutput(ioutil.Discard)
+	} else {
+		detector.Logger = log.Default()
 	}
 
-	var (
-		default_input_mode_git bool
-		default_input_mode_fs  bool
-	)
+	if input_rules != "" {
+		loadRules(input_rules)
+	}
+	for _, pattern := range input_vendor_patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			checkErr(fmt.Errorf("invalid -vendor-pattern: %v", err))
+		}
+		detector.AddVendorPattern(re)
+	}
+	for _, pattern := range input_test_patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			checkErr(fmt.Errorf("invalid -test-pattern: %v", err))
+		}
+		test_patterns = append(test_patterns, re)
+	}
+	detector.ContentPriority = input_content_priority
 
-	if !input_mode_fs && findGitDir() { // side-effect: cd's to GIT_DIR!
-		default_input_mode_git = true
-		default_input_mode_fs = false
+	if output_path != "" && output_path != "-" {
+		// resolve before findGitDir() changes the working directory
+		p, err := filepath.Abs(output_path)
+		checkErr(err)
+		output_path = p
+	}
+	if output_markdown_base != "" {
+		// also before findGitDir()
+		loadMarkdownBase(output_markdown_base)
+	}
+
+	var result linguist.Result
+	if output_merge {
+		openOutput(output_path)
+		result = mergeResults(flag.Args())
 	} else {
-		default_input_mode_git = false
-		default_input_mode_fs = true
+		stopProfiles := startProfiles()
+		result = scan()
+		stopProfiles()
+	}
+	if output_group {
+		result = groupResult(result)
 	}
 
-	if !input_mode_git && !input_mode_fs {
-		input_mode_git = default_input_mode_git
-		input_mode_fs = default_input_mode_fs
+	if output_list_files || output_ndjson {
+		closeOutput()
+		os.Exit(0)
 	}
 
-	if !input_mode_git && input_git_tree != "HEAD" {
-		input_mode_git = true
-		input_mode_fs = false
+	if output_dot {
+		writeDot()
+		closeOutput()
+		os.Exit(0)
 	}
 
-	if input_mode_git && input_mode_fs {
-		fmt.Println("Please choose one of -git or -fs as flags, but not both.")
-		fmt.Println("You can omit the flags to get the default behavior,")
-		fmt.Printf("which for the current directory is %s\n", func() string {
-			switch {
-			case default_input_mode_git:
-				return "git"
-			case default_input_mode_fs:
-				return "fs"
-			}
-			return "undefined"
-		}())
-		os.Exit(1)
+	if output_by_author {
+		writeByAuthor()
+		closeOutput()
+		os.Exit(0)
 	}
 
-	if input_mode_fs {
-		initGitIgnore()
-		processDir(".")
+	if output_summary_split {
+		writeSummarySplit()
+		closeOutput()
+		os.Exit(0)
 	}
 
-	if input_mode_git {
-		repo, err := git4go.OpenRepository(".")
-		checkErr(err)
-		ref, err := repo.DwimReference(input_git_tree)
-		checkErr(err)
-		resolved, err := ref.Resolve()
-		checkErr(err)
-		odb, err := repo.Odb()
+	scanned_size := result.TotalSize + ignored_size
+	if types := splitTypes(output_types); len(types) > 0 {
+		result = result.Filter(func(lang string, _ int) bool {
+			// Other from reports given to -merge could be of any type
+			return lang == "Other" || hasString(types, linguist.LanguageType(lang))
+		})
+	}
+
+	// no need to fold anything
+	if output_totals {
+		json_bytes, err := marshalJSON(totalsOf(result))
 		checkErr(err)
-		processTree(repo, odb, resolved.Target(), []string{})
+		fmt.Fprintln(output, string(json_bytes))
+		closeOutput()
+		os.Exit(0)
 	}
 
 	results := []*language{}
-	for lang, size := range langs {
-		percent := float64 (float64(size) / float64(total_size)) * 100.0
+	for _, stat := range result.Languages {
+		percent := stat.Percent
+		if output_percent_base == "scanned" {
+			percent = 0.0
+			if scanned_size > 0 {
+				percent = float64(stat.Size) / float64(scanned_size) * 100.0
+			}
+		}
 		results = append(results, &language{
-			Language: lang,
-			Percent: percent, 
-                        Percentage: fmt.Sprintf("%.2f", percent),
-			Size: size,
+			Language:   stat.Name,
+			Percent:    percent,
+			Percentage: fmt.Sprintf("%.2f", percent),
+			Size:       stat.Size,
+			Files:      stat.Files,
+			Examples:   examples[stat.Name],
 		})
 	}
 
-	sort.Sort(sort.Reverse(sortableResult(results)))
+	results = foldOther(results)
 
-	if output_limit > 0 && len(results) > output_limit {
-		other := &language{
-			Language: "Other",
-		}
-		for i := output_limit; i < len(results); i++ {
-			other.Percent += results[i].Percent
-			other.Size += 0
-		}
-		results = append(results[0:output_limit], other)
+	if output_fingerprint {
+		writeFingerprint(results)
+		closeOutput()
+		os.Exit(0)
+	}
+	if output_stats_json {
+		writeStatsJSON(result, results)
+		closeOutput()
+		os.Exit(0)
 	}
 
 	if output_json {
@@ -241,23 +960,76 @@ func main() {
 		if output_json_with_colors {
 			out := []*language_color{}
 			for _, lang := range results {
-				out = append(out, &language_color{lang.Language, lang.Percent, linguist.LanguageColor(lang.Language)})
+				out = append(out, &language_color{lang.Language, lang.Percent, formattedColor(lang.Language), lang.Examples})
 			}
-			json_bytes, err = json.MarshalIndent(out, "", "  ")
+			json_bytes, err = marshalJSON(withMetadata(out))
 		} else {
-			json_bytes, err = json.MarshalIndent(makeMap(results), "", "  ")
+			json_bytes, err = marshalJSON(withMetadata(makeMap(results)))
 		}
 		checkErr(err)
-		fmt.Println(string(json_bytes))
+		fmt.Fprintln(output, string(json_bytes))
+		closeOutput()
+		os.Exit(0)
+	}
+	if output_markdown {
+		writeMarkdown(result, results)
+		closeOutput()
+		os.Exit(0)
+	}
+	if output_tmpl != nil {
+		writeTemplate(result, results)
+		closeOutput()
 		os.Exit(0)
 	}
-	fmtstr := fmt.Sprintf("%% %ds", max_len)
-	fmtstr += ": %07.4f%%\n"
+
+	fmtstr := fmt.Sprintf("%% %ds", nameWidth(results))
+	color := useColor()
 
 	for _, l := range results {
-		fmt.Printf(fmtstr, l.Language, l.Percent)
+		name := fmt.Sprintf(fmtstr, l.Language)
+		if color {
+			name = colorize(name, languageColor(l.Language))
+		}
+		fmt.Fprintf(output, "%s: %07.4f%%\n", name, l.Percent)
 	}
 
-	fmt.Printf("\n%d language%s detected in %d file%s\n", len(results), pluralize(len(results)), num_files, pluralize(num_files))
-	fmt.Printf("%d ignored path%s\n", ignored_paths, pluralize(ignored_paths))
+	if !output_no_footer {
+		if len(results) == 0 {
+			fmt.Fprintln(output, "no files detected")
+		} else {
+			fmt.Fprintln(output, "\n"+footer(result, len(results)))
+		}
+		fmt.Fprintf(output, "%d ignored path%s\n", result.IgnoredPaths, pluralize(result.IgnoredPaths))
+		if result.Unreadable > 0 {
+			fmt.Fprintf(output, "%d unreadable path%s, skipped\n", result.Unreadable, pluralize(result.Unreadable))
+		}
+		if result.Sampled {
+			fmt.Fprintf(output, "sampled: stopped after %d file%s (-max-files)\n", input_max_files, pluralize(input_max_files))
+		}
+		if len(results) > 0 {
+			// of the language with the most bytes, whatever the -sort order
+			top := results[0]
+			for _, l := range results {
+				if l.Size > top.Size && l.Language != "Other" {
+					top = l
+				}
+			}
+			if l, ok := largest[top.Language]; ok {
+				fmt.Fprintf(output, "largest %s file: %s (%s)\n", top.Language, displayPath(l.path), humanizeBytes(l.size))
+			}
+		}
+	}
+	if output_report_eol {
+		counts := []string{}
+		for _, eol := range []string{linguist.EOLUnix, linguist.EOLWindows, linguist.EOLMac, linguist.EOLMixed} {
+			if eol_counts[eol] > 0 {
+				counts = append(counts, fmt.Sprintf("%d %s", eol_counts[eol], eol))
+			}
+		}
+		if len(counts) == 0 {
+			counts = append(counts, "none")
+		}
+		fmt.Fprintf(output, "line endings: %s\n", strings.Join(counts, ", "))
+	}
+	closeOutput()
 }
@@ -0,0 +1,508 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestMain runs main instead of the tests when the test binary is run by
+// runL, so that tests can run l with flags, leaving its globals untouched.
+func TestMain(m *testing.M) {
+	if os.Getenv("L_TEST_MAIN") == "1" {
+		main()
+		closeOutput()
+		os.Exit(0)
+	}
+	// as main does without -debug
+	log.SetOutput(ioutil.Discard)
+	os.Exit(m.Run())
+}
+
+// runL runs l in dir with args, returning what it wrote to standard output
+// and whether it succeeded
+func runL(t *testing.T, dir string, args ...string) (string, error) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "L_TEST_MAIN=1")
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	err := cmd.Run()
+	if stderr.Len() > 0 {
+		t.Logf("l %s: %s", strings.Join(args, " "), stderr.String())
+	}
+	return stdout.String(), err
+}
+
+// mustRunL is runL, failing the test if l does not succeed
+func mustRunL(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	out, err := runL(t, dir, args...)
+	if err != nil {
+		t.Fatalf("l %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return out
+}
+
+// writeFiles creates the files, with the given contents, below dir
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for path, contents := range files {
+		path = filepath.Join(dir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// runJSON runs l with -json and args in dir, returning the results by language
+func runJSON(t *testing.T, dir string, args ...string) map[string]*language {
+	t.Helper()
+	out := mustRunL(t, dir, append([]string{"-json"}, args...)...)
+	results := map[string]*language{}
+	if err := json.Unmarshal([]byte(out), &results); err != nil {
+		t.Fatalf("l -json %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return results
+}
+
+func TestCapFileSize(t *testing.T) {
+	dir := t.TempDir()
+	big := "package big\n" + strings.Repeat("// padding\n", 10000)
+	writeFiles(t, dir, map[string]string{"big.go": big, "small.py": "x = 1\n"})
+
+	results := runJSON(t, dir, "-fs")
+	if got := results["Go"].Size; got != len(big) {
+		t.Errorf("Go: %d bytes, want %d", got, len(big))
+	}
+	results = runJSON(t, dir, "-fs", "-cap-file-size", "100")
+	if got := results["Go"].Size; got != 100 {
+		t.Errorf("Go with -cap-file-size 100: %d bytes, want 100", got)
+	}
+	if got := results["Python"].Size; got != 6 {
+		t.Errorf("Python with -cap-file-size 100: %d bytes, want 6", got)
+	}
+
+	// the cap applies to a split file as a whole
+	writeFiles(t, dir, map[string]string{
+		"index.html": "<html><script>" + strings.Repeat("x = 1;\n", 30) + "</script><style>" + strings.Repeat("p {}\n", 30) + "</style></html>\n",
+	})
+	results = runJSON(t, dir, "-fs", "-split-embedded", "-cap-file-size", "100")
+	html := 0
+	for _, lang := range []string{"HTML", "JavaScript", "CSS"} {
+		if results[lang] == nil {
+			t.Fatalf("-split-embedded -cap-file-size 100: no %s in %v", lang, results)
+		}
+		html += results[lang].Size
+	}
+	if html != 100 {
+		t.Errorf("-split-embedded -cap-file-size 100: index.html counts %d bytes, want 100", html)
+	}
+}
+
+func TestJSONCompact(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"main.go": "package main\n", "util.py": "x = 1\n"})
+
+	out := mustRunL(t, dir, "-fs", "-json-compact")
+	if strings.Count(out, "\n") != 1 || !strings.HasSuffix(out, "}\n") {
+		t.Errorf("-json-compact: not a single line:\n%s", out)
+	}
+	var results map[string]*language
+	if err := json.Unmarshal([]byte(out), &results); err != nil || results["Go"] == nil {
+		t.Errorf("-json-compact: %v, %v\n%s", results, err, out)
+	}
+	if out := mustRunL(t, dir, "-fs", "-json"); strings.Count(out, "\n") == 1 {
+		t.Errorf("-json: a single line:\n%s", out)
+	}
+}
+
+func TestHumanizeBytes(t *testing.T) {
+	for n, want := range map[int]string{
+		0:            "0 B",
+		1023:         "1023 B",
+		1024:         "1.0 KiB",
+		1536:         "1.5 KiB",
+		1 << 20:      "1.0 MiB",
+		5 << 30:      "5.0 GiB",
+		3 << 40:      "3.0 TiB",
+		4096 << 40:   "4096.0 TiB",
+		1<<20 - 1:    "1024.0 KiB",
+		10*1<<20 + 1: "10.0 MiB",
+	} {
+		if got := humanizeBytes(n); got != want {
+			t.Errorf("humanizeBytes(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestNoNaN(t *testing.T) {
+	empty := t.TempDir()
+	zeros := t.TempDir()
+	writeFiles(t, zeros, map[string]string{"a.go": "", "b.py": "", "sub/c.rb": ""})
+	for _, dir := range []string{empty, zeros} {
+		for _, args := range [][]string{
+			{"-fs"},
+			{"-fs", "-json"},
+			{"-fs", "-json-with-colors"},
+			{"-fs", "-markdown"},
+			{"-fs", "-stats-json"},
+			{"-fs", "-summary-split"},
+			{"-fs", "-percent-base", "scanned", "-skip-empty"},
+		} {
+			if out := mustRunL(t, dir, args...); strings.Contains(out, "NaN") {
+				t.Errorf("l %s: %s", strings.Join(args, " "), out)
+			}
+		}
+	}
+}
+
+func TestMinBytesAndLimit(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.go": "package a\n" + strings.Repeat("//\n", 330),
+		"b.py": strings.Repeat("x = 1\n", 80),
+		"c.rb": strings.Repeat("puts 1\n", 40),
+		"d.sh": "echo 1\n",
+		"e.pl": "print 1;\n",
+	})
+	results := runJSON(t, dir, "-fs", "-min-bytes", "100", "-limit", "2")
+	if len(results) != 3 || results["Go"] == nil || results["Python"] == nil {
+		t.Fatalf("-min-bytes 100 -limit 2: got %v, want Go, Python and Other", results)
+	}
+	// Ruby beyond -limit, and Shell and Perl below -min-bytes
+	if got, want := results["Other"].Size, 280+7+9; got != want {
+		t.Errorf("Other: %d bytes, want %d", got, want)
+	}
+
+	results = runJSON(t, dir, "-fs", "-min-bytes", "100", "-limit", "3")
+	if len(results) != 4 || results["Ruby"] == nil || results["Other"].Size != 7+9 {
+		t.Errorf("-min-bytes 100 -limit 3: got %v, want Go, Python, Ruby and Other", results)
+	}
+
+	results = runJSON(t, dir, "-fs", "-min-bytes", "100", "-limit", "2", "-no-other")
+	if len(results) != 2 || results["Other"] != nil {
+		t.Errorf("-no-other: got %v, want Go and Python", results)
+	}
+}
+
+func TestByAuthorMinBytesAndLimit(t *testing.T) {
+	g := newGitFixture(t)
+	g.commit("first", map[string]string{
+		"a.go": "package a\n" + strings.Repeat("//\n", 330),
+		"b.py": strings.Repeat("x = 1\n", 80),
+		"c.rb": strings.Repeat("puts 1\n", 40),
+		"d.sh": "echo 1\n",
+	})
+	for _, tt := range []struct {
+		args  []string
+		want  []string
+		other int
+	}{
+		{[]string{"-limit", "2"}, []string{"Go", "Python", "Other"}, 280 + 7},
+		{[]string{"-min-bytes", "100"}, []string{"Go", "Python", "Ruby", "Other"}, 7},
+		{[]string{"-limit", "1", "-no-other"}, []string{"Go"}, 0},
+	} {
+		out := mustRunL(t, g.dir, append([]string{"-by-author", "-json"}, tt.args...)...)
+		var got []*authorResult
+		if err := json.Unmarshal([]byte(out), &got); err != nil || len(got) != 1 {
+			t.Fatalf("-by-author %v: %v\n%s", tt.args, err, out)
+		}
+		langs, other := []string{}, 0
+		for _, l := range got[0].Languages {
+			langs = append(langs, l.Language)
+			if l.Language == "Other" {
+				other = l.Size
+			}
+		}
+		if !reflect.DeepEqual(langs, tt.want) || other != tt.other {
+			t.Errorf("-by-author %v: got %v with %d bytes of Other, want %v with %d", tt.args, langs, other, tt.want, tt.other)
+		}
+	}
+
+	out := mustRunL(t, g.dir, "-by-author", "-limit", "1")
+	if lines := strings.Split(strings.TrimSpace(out), "\n"); len(lines) != 3 || !strings.HasPrefix(strings.TrimSpace(lines[2]), "Other:") {
+		t.Errorf("-by-author -limit 1: got\n%s\nwant the author, Go and Other", out)
+	}
+}
+
+func TestExamples(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{"util.py": "x = 1\n"}
+	for _, name := range []string{"a.go", "b.go", "c.go", "d.go", "e.go"} {
+		files[name] = "package main\n"
+	}
+	writeFiles(t, dir, files)
+
+	results := runJSON(t, dir, "-fs", "-examples", "2")
+	if got := results["Go"].Examples; len(got) != 2 {
+		t.Errorf("Go: examples %v, want 2", got)
+	}
+	if got := results["Python"].Examples; len(got) != 1 || got[0] != "util.py" {
+		t.Errorf("Python: examples %v, want [util.py]", got)
+	}
+	if results := runJSON(t, dir, "-fs"); results["Go"].Examples != nil {
+		t.Errorf("without -examples: %v", results["Go"].Examples)
+	}
+}
+
+func TestTotals(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"main.go":       "package main\n",
+		"util.py":       "x = 1\n",
+		"lib.rb":        "puts 1\n",
+		"vendor/lib.js": "var x;\n",
+	})
+	// -limit does not change the number of languages
+	out := mustRunL(t, dir, "-fs", "-totals", "-limit", "1")
+	var got map[string]interface{}
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("-totals: %v\n%s", err, out)
+	}
+	want := map[string]interface{}{
+		"total_size":      float64(13 + 6 + 7),
+		"total_files":     float64(3),
+		"total_languages": float64(3),
+		"ignored_paths":   float64(1),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("-totals: got %v, want %v", got, want)
+	}
+}
+
+func TestFooter(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"main.go":     "package main\n",
+		"b.GO":        "package main\n",
+		"util.py":     "x = 1\n",
+		"Makefile":    "all:\n",
+		"web/app.js":  "v\n",
+		"vendor/x.rb": "puts 1\n",
+	})
+	out := mustRunL(t, dir, "-fs")
+	// extensions are counted regardless of case, vendored files not at all
+	if want := "\n4 languages detected in 5 files (39 B, 3 extensions)\n1 ignored path\n"; !strings.Contains(out, want) {
+		t.Errorf("got\n%s\nwant the footer %q", out, want)
+	}
+	writeFiles(t, dir, map[string]string{"more.py": strings.Repeat("x = 1\n", 200)})
+	if want := "\n4 languages detected in 6 files (1.2 KiB, 3 extensions)\n"; !strings.Contains(mustRunL(t, dir, "-fs"), want) {
+		t.Errorf("want the footer %q", want)
+	}
+}
+
+func TestSort(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.go":    "package main\n",
+		"b.go":    "package main\n",
+		"c.go":    "package main\n",
+		"util.py": strings.Repeat("x = 1\n", 10),
+		"a.rb":    "puts 1\n",
+		"b.rb":    "puts 1\n",
+	})
+	languages := func(args ...string) []string {
+		t.Helper()
+		var names []string
+		for _, line := range strings.Split(strings.TrimSpace(mustRunL(t, dir, append([]string{"-fs", "-no-footer"}, args...)...)), "\n") {
+			names = append(names, strings.TrimSpace(strings.Split(line, ":")[0]))
+		}
+		return names
+	}
+	for _, tt := range []struct {
+		args []string
+		want []string
+	}{
+		{nil, []string{"Python", "Go", "Ruby"}},
+		{[]string{"-sort", "desc"}, []string{"Python", "Go", "Ruby"}},
+		{[]string{"-sort", "asc"}, []string{"Ruby", "Go", "Python"}},
+		{[]string{"-sort", "name"}, []string{"Go", "Python", "Ruby"}},
+		{[]string{"-sort", "files"}, []string{"Go", "Ruby", "Python"}},
+		// -limit keeps the largest, with Other last
+		{[]string{"-sort", "asc", "-limit", "2"}, []string{"Go", "Python", "Other"}},
+	} {
+		if got := languages(tt.args...); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%v: got %v, want %v", tt.args, got, tt.want)
+		}
+	}
+	if out, err := runL(t, dir, "-fs", "-sort", "size"); err == nil {
+		t.Errorf("-sort size: got\n%s\nwant an error", out)
+	}
+}
+
+func TestNoFooter(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"main.go":       "package main\n",
+		"util.py":       "x = 1\n",
+		"vendor/lib.rb": "puts 1\n",
+	})
+	out := mustRunL(t, dir, "-fs")
+	for _, line := range []string{"2 languages detected", "1 ignored path", "largest Go file"} {
+		if !strings.Contains(out, line) {
+			t.Errorf("-fs: got\n%s\nwant %q", out, line)
+		}
+	}
+	for _, args := range [][]string{{"-no-footer"}, {"-no-footer", "-markdown"}} {
+		out := mustRunL(t, dir, append([]string{"-fs"}, args...)...)
+		for _, line := range []string{"detected", "ignored", "largest"} {
+			if strings.Contains(out, line) {
+				t.Errorf("%v: got\n%s\nwant no %q", args, out, line)
+			}
+		}
+		if !strings.Contains(out, "Go") || !strings.Contains(out, "Python") {
+			t.Errorf("%v: got\n%s\nwant the languages", args, out)
+		}
+	}
+	if out := mustRunL(t, dir, "-fs", "-no-footer", "-report-eol"); !strings.HasSuffix(out, "line endings: 2 LF\n") {
+		t.Errorf("-no-footer -report-eol: got\n%s", out)
+	}
+}
+
+func TestVerboseOther(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"main.go":   strings.Repeat("package main\n", 10),
+		"util.py":   strings.Repeat("x = 1\n", 10),
+		"a.rb":      "puts 1\n",
+		"b.rb":      "puts 2\n",
+		"deploy.sh": "echo\n",
+	})
+	results := runJSON(t, dir, "-fs", "-limit", "2", "-verbose-other")
+	other := results["Other"]
+	if other == nil || other.Size != 19 || other.Files != 3 {
+		t.Fatalf("-verbose-other: got %v, want Other with Ruby and Shell", results)
+	}
+	var folded []string
+	for _, l := range other.Languages {
+		folded = append(folded, fmt.Sprintf("%s %d %d", l.Language, l.Size, l.Files))
+	}
+	if want := []string{"Ruby 14 2", "Shell 5 1"}; !reflect.DeepEqual(folded, want) {
+		t.Errorf("-verbose-other: Other has %v, want %v", folded, want)
+	}
+	if results := runJSON(t, dir, "-fs", "-limit", "2"); results["Other"] == nil || results["Other"].Languages != nil {
+		t.Errorf("without -verbose-other: got %+v", results["Other"])
+	}
+}
+
+func TestPercentBase(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		".git/HEAD":     "ref: refs/heads/main\n",
+		".gitignore":    "build/\n",
+		"main.go":       strings.Repeat("package main\n", 3),
+		"util.py":       strings.Repeat("x = 1\n", 2),
+		"vendor/lib.rb": strings.Repeat("puts 1\n", 3),
+		"docs/guide.md": strings.Repeat("# Guide\n", 2),
+		"build/out.js":  strings.Repeat("var x = 1;\n", 100),
+	})
+	for _, tt := range []struct {
+		base       string
+		goPercent  float64
+		pyPercent  float64
+		totalBytes int
+	}{
+		{"counted", 39.0 / 51 * 100, 12.0 / 51 * 100, 51},
+		// vendor, docs and .gitignore are scanned, build/ is not
+		{"scanned", 39.0 / 95 * 100, 12.0 / 95 * 100, 95},
+	} {
+		results := runJSON(t, dir, "-fs", "-percent-base", tt.base)
+		if len(results) != 2 || math.Abs(results["Go"].Percent-tt.goPercent) > 1e-9 || math.Abs(results["Python"].Percent-tt.pyPercent) > 1e-9 {
+			t.Errorf("-percent-base %s: got %v, want Go at %v%% and Python at %v%% of %d bytes", tt.base,
+				results, tt.goPercent, tt.pyPercent, tt.totalBytes)
+		}
+		// sizes are unaffected
+		if results["Go"].Size != 39 || results["Python"].Size != 12 {
+			t.Errorf("-percent-base %s: got %v", tt.base, results)
+		}
+	}
+	if results := runJSON(t, dir, "-fs"); math.Abs(results["Go"].Percent-39.0/51*100) > 1e-9 {
+		t.Errorf("default: got Go at %v%%, want counted bytes", results["Go"].Percent)
+	}
+	if out, err := runL(t, dir, "-fs", "-percent-base", "all"); err == nil {
+		t.Errorf("-percent-base all: got\n%s\nwant an error", out)
+	}
+}
+
+func TestType(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"main.go":     "package main\n",
+		"index.html":  "<html></html>\n",
+		"api.rst":     "API\n===\n",
+		"design.adoc": "= Design\n",
+		"notes.org":   "* Notes\n",
+	})
+	if results := runJSON(t, dir, "-fs"); len(results) != 5 {
+		t.Errorf("-fs: got %v, want 5 languages", results)
+	}
+	if results := runJSON(t, dir, "-fs", "-type", "programming"); len(results) != 1 || results["Go"].Percent != 100 {
+		t.Errorf("-type programming: got %v, want Go only", results)
+	}
+	if results := runJSON(t, dir, "-fs", "-type", "programming,markup"); len(results) != 2 || results["HTML"] == nil {
+		t.Errorf("-type programming,markup: got %v, want Go and HTML", results)
+	}
+	if results := runJSON(t, dir, "-fs", "-type", "prose"); len(results) != 3 || results["reStructuredText"] == nil || results["AsciiDoc"] == nil || results["Org"] == nil {
+		t.Errorf("-type prose: got %v, want reStructuredText, AsciiDoc and Org", results)
+	}
+}
+
+func TestMaxFiles(t *testing.T) {
+	files := map[string]string{
+		"a.go": "package a\n",
+		"b.go": "package a\n",
+		"c.py": "x = 1\n",
+		"d.py": "x = 1\n",
+		"e.rb": "puts 1\n",
+	}
+	dir := t.TempDir()
+	writeFiles(t, dir, files)
+	g := newGitFixture(t)
+	g.commit("files", files)
+	for _, tt := range []struct {
+		dir  string
+		mode string
+	}{
+		{dir, "-fs"},
+		{g.dir, "-git"},
+	} {
+		for _, n := range []int{1, 3} {
+			out := mustRunL(t, tt.dir, tt.mode, "-totals", "-max-files", fmt.Sprint(n))
+			var got totals
+			if err := json.Unmarshal([]byte(out), &got); err != nil {
+				t.Fatalf("%s -max-files %d: %v\n%s", tt.mode, n, err, out)
+			}
+			if got.TotalFiles != n || !got.Sampled {
+				t.Errorf("%s -max-files %d: got %+v, want %d files, sampled", tt.mode, n, got, n)
+			}
+		}
+		// nothing is left out
+		out := mustRunL(t, tt.dir, tt.mode, "-totals", "-max-files", "5")
+		var got totals
+		if err := json.Unmarshal([]byte(out), &got); err != nil {
+			t.Fatalf("%s -max-files 5: %v\n%s", tt.mode, err, out)
+		}
+		if got.TotalFiles != 5 || got.Sampled {
+			t.Errorf("%s -max-files 5: got %+v, want 5 files, not sampled", tt.mode, got)
+		}
+	}
+
+	out := mustRunL(t, dir, "-fs", "-max-files", "2")
+	if !strings.Contains(out, "sampled: stopped after 2 files (-max-files)\n") {
+		t.Errorf("-max-files 2: not marked as sampled in\n%s", out)
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dayvonjersen/linguist"
+)
+
+// colored square emoji, for a swatch of a language's color in markdown
+var swatches = []struct {
+	emoji   string
+	r, g, b int
+}{
+	{"🟥", 0xdd, 0x2e, 0x44},
+	{"🟧", 0xf4, 0x90, 0x0c},
+	{"🟨", 0xfd, 0xcb, 0x58},
+	{"🟩", 0x78, 0xb1, 0x59},
+	{"🟦", 0x55, 0xac, 0xee},
+	{"🟪", 0xaa, 0x8e, 0xd6},
+	{"🟫", 0xc1, 0x69, 0x4f},
+	{"⬛", 0x29, 0x2f, 0x33},
+	{"⬜", 0xe6, 0xe7, 0xe8},
+}
+
+// swatch returns the emoji closest to the color hex, or "" if hex is not a
+// valid color
+func swatch(hex string) string {
+	r, g, b, ok := parseHexColor(hex)
+	if !ok {
+		return ""
+	}
+	best, best_dist := "", -1
+	for _, s := range swatches {
+		dr, dg, db := int(r)-s.r, int(g)-s.g, int(b)-s.b
+		if dist := dr*dr + dg*dg + db*db; best_dist < 0 || dist < best_dist {
+			best, best_dist = s.emoji, dist
+		}
+	}
+	return best
+}
+
+// the percentage of each language in the report given to -markdown-base
+var markdown_base map[string]float64
+
+// loadMarkdownBase reads the report given to -markdown-base
+func loadMarkdownBase(filename string) {
+	report := readReport(filename)
+	total := 0
+	for _, l := range report {
+		total += l.Size
+	}
+	markdown_base = map[string]float64{}
+	for lang, l := range report {
+		if total > 0 {
+			markdown_base[lang] = float64(l.Size) / float64(total) * 100
+		}
+	}
+}
+
+// writeMarkdown writes results, shown for r, as a GitHub flavored markdown
+// table for -markdown, e.g. for posting as a comment on a pull request.
+//
+// With -markdown-base, a Delta column shows how the percentage of each
+// language changed since that report, followed by rows for the languages
+// which are gone. The share of Other in the report is what the languages shown
+// don't make up of it, so that the deltas add up to zero.
+func writeMarkdown(r linguist.Result, results []*language) {
+	if len(results) == 0 && markdown_base == nil {
+		fmt.Fprintln(output, "no files detected")
+		return
+	}
+	if markdown_base == nil {
+		fmt.Fprintln(output, "| | Language | Percent | Size |")
+		fmt.Fprintln(output, "|---|---|---:|---:|")
+		for _, l := range results {
+			fmt.Fprintf(output, "| %s | %s | %.2f%% | %s |\n", swatch(languageColor(l.Language)), markdownEscape(l.Language), l.Percent, humanizeBytes(l.Size))
+		}
+	} else {
+		shown := map[string]bool{}
+		for _, l := range results {
+			shown[l.Language] = true
+		}
+		var gone []string
+		for lang := range markdown_base {
+			if r.Language(lang) == nil && !shown[lang] && lang != "Other" {
+				gone = append(gone, lang)
+			}
+		}
+		sort.Strings(gone)
+		base_other := 100.0
+		for _, l := range results {
+			if l.Language != "Other" {
+				base_other -= markdown_base[l.Language]
+			}
+		}
+		for _, lang := range gone {
+			base_other -= markdown_base[lang]
+		}
+
+		fmt.Fprintln(output, "| | Language | Percent | Size | Delta |")
+		fmt.Fprintln(output, "|---|---|---:|---:|---:|")
+		for _, l := range results {
+			base := markdown_base[l.Language]
+			if l.Language == "Other" {
+				base = base_other
+			}
+			fmt.Fprintf(output, "| %s | %s | %.2f%% | %s | %s |\n", swatch(languageColor(l.Language)), markdownEscape(l.Language), l.Percent, humanizeBytes(l.Size), delta(l.Percent-base))
+		}
+		for _, lang := range gone {
+			fmt.Fprintf(output, "| %s | %s | %.2f%% | %s | %s |\n", swatch(languageColor(lang)), markdownEscape(lang), 0.0, humanizeBytes(0), delta(-markdown_base[lang]))
+		}
+	}
+	if !output_no_footer {
+		fmt.Fprintln(output, "\n"+footer(r, len(results)))
+	}
+}
+
+// delta formats a change in percentage points, e.g. "+1.50%" or "0.00%"
+func delta(d float64) string {
+	if s := fmt.Sprintf("%+.2f%%", d); s != "+0.00%" && s != "-0.00%" {
+		return s
+	}
+	return "0.00%"
+}
+
+func markdownEscape(s string) string {
+	return strings.Replace(s, "|", `\|`, -1)
+}
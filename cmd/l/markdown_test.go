@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/dayvonjersen/linguist"
+)
+
+func TestWriteMarkdown(t *testing.T) {
+	// as counted, before TOML was folded into Other
+	sizes := map[string]int{"Go": 3000, "YAML": 750, "TOML": 250}
+	r := linguist.NewResult(sizes, map[string]int{"Go": 3, "YAML": 1, "TOML": 2}, 1)
+	var results []*language
+	for _, stat := range r.Languages {
+		name := stat.Name
+		if name == "TOML" {
+			name = "Other"
+		}
+		results = append(results, &language{Language: name, Percent: stat.Percent, Size: stat.Size})
+	}
+
+	for _, tt := range []struct {
+		golden string
+		base   map[string]float64
+	}{
+		{"markdown.golden", nil},
+		// Shell is gone since, and TOML is folded into Other
+		{"markdown_delta.golden", map[string]float64{"Go": 60, "YAML": 20, "Shell": 15, "TOML": 5}},
+	} {
+		markdown_base = tt.base
+		var buf bytes.Buffer
+		output = &buf
+		writeMarkdown(r, results)
+		want, err := ioutil.ReadFile(filepath.Join("testdata", tt.golden))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := buf.String(); got != string(want) {
+			t.Errorf("%s: got\n%s\nwant\n%s", tt.golden, got, want)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+
+	"github.com/dayvonjersen/linguist"
+)
+
+// a language in a report written with -json
+type reportEntry struct {
+	Size  int `json:"size"`
+	Files int `json:"files"`
+}
+
+// mergeResults sums up the reports written with -json in filenames, see
+// -merge, as if their files had been scanned.
+func mergeResults(filenames []string) linguist.Result {
+	if len(filenames) == 0 {
+		checkErr(fmt.Errorf("-merge needs at least one report written with -json"))
+	}
+	sizes, files := map[string]int{}, map[string]int{}
+	for _, filename := range filenames {
+		report := readReport(filename)
+		log.Println("merging", len(report), "languages from", filename)
+		for lang, l := range report {
+			// the languages of Other are unknown, so it stays Other
+			sizes[lang] += l.Size
+			files[lang] += l.Files
+		}
+	}
+	return linguist.NewResult(sizes, files, 0)
+}
+
+// readReport reads a report written with -json, for -merge and
+// -markdown-base.
+//
+// A report is an object with an entry for each language, of which only the
+// number of bytes ("size") and files ("files", if present) are used, or such
+// an object as "results" alongside the metadata of -record-invocation and
+// -record-commit.
+func readReport(filename string) map[string]reportEntry {
+	data, err := ioutil.ReadFile(filename)
+	checkErr(err)
+	var recorded struct {
+		metadata
+		Results json.RawMessage `json:"results"`
+	}
+	if json.Unmarshal(data, &recorded) == nil && (recorded.Invocation != nil || recorded.Commit != nil) {
+		if recorded.Invocation != nil {
+			log.Println(filename, "was written by", recorded.Invocation.Args, "in", recorded.Invocation.Dir)
+		}
+		if recorded.Commit != nil {
+			log.Println(filename, "is of commit", recorded.Commit.SHA)
+		}
+		data = recorded.Results
+	}
+	var report map[string]reportEntry
+	if err := json.Unmarshal(data, &report); err != nil {
+		checkErr(fmt.Errorf("%s: not a report written with -json: %v", filename, err))
+	}
+	return report
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	reports := []string{}
+	for _, name := range []string{"merge_api.json", "merge_web.json"} {
+		path, err := filepath.Abs(filepath.Join("testdata", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		reports = append(reports, path)
+	}
+	results := runJSON(t, t.TempDir(), append([]string{"-merge"}, reports...)...)
+	// the languages of Other in a report are unknown, so stay Other
+	want := map[string]reportEntry{
+		"TypeScript": {4000, 20},
+		"Go":         {3000, 12},
+		"YAML":       {1800, 4},
+		"Other":      {200, 2},
+	}
+	if len(results) != len(want) {
+		t.Errorf("-merge: got %v, want %v", results, want)
+	}
+	for lang, w := range want {
+		if l := results[lang]; l == nil || l.Size != w.Size || l.Files != w.Files {
+			t.Errorf("-merge: %s: got %+v, want %+v", lang, l, w)
+		}
+	}
+	if got := results["TypeScript"].Percent; got != 4000.0/9000*100 {
+		t.Errorf("-merge: TypeScript at %v%%, want its share of the total", got)
+	}
+
+	// Other grows with the languages folded into it here
+	results = runJSON(t, t.TempDir(), append([]string{"-merge", "-limit", "2"}, reports...)...)
+	if l := results["Other"]; len(results) != 3 || l == nil || l.Size != 2000 || l.Files != 6 {
+		t.Errorf("-merge -limit 2: got %v, want Other with YAML", results)
+	}
+
+	if out, err := runL(t, t.TempDir(), "-merge"); err == nil {
+		t.Errorf("-merge without reports: got\n%s\nwant an error", out)
+	}
+}
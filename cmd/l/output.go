@@ -0,0 +1,109 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// where results are written, see -o
+var output io.Writer = os.Stdout
+
+// atomicFile collects output in a temporary file next to its destination,
+// which is only renamed into place by Commit, so that readers never see a
+// partially written file.
+type atomicFile struct {
+	*os.File
+	path string
+}
+
+// createAtomic creates the temporary file for path, with the mode of path if
+// it exists already, or else 0666 less the umask, as for any new file, rather
+// than the 0600 of ioutil.TempFile, so that e.g. CI artifacts stay readable.
+func createAtomic(path string) (*atomicFile, error) {
+	mode, keep := os.FileMode(0666), false
+	if fi, err := os.Stat(path); err == nil {
+		mode, keep = fi.Mode().Perm(), true
+	}
+	prefix := filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	for i := 0; ; i++ {
+		f, err := os.OpenFile(fmt.Sprintf("%s%d.%d", prefix, os.Getpid(), i), os.O_RDWR|os.O_CREATE|os.O_EXCL, mode)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if keep {
+			// regardless of the umask
+			if err := f.Chmod(mode); err != nil {
+				f.Close()
+				os.Remove(f.Name())
+				return nil, err
+			}
+		}
+		return &atomicFile{File: f, path: path}, nil
+	}
+}
+
+// Commit flushes the temporary file to disk and renames it over path.
+func (a *atomicFile) Commit() error {
+	if err := a.Sync(); err != nil {
+		a.Abort()
+		return err
+	}
+	if err := a.Close(); err != nil {
+		os.Remove(a.Name())
+		return err
+	}
+	if err := os.Rename(a.Name(), a.path); err != nil {
+		os.Remove(a.Name())
+		return err
+	}
+	return nil
+}
+
+// Abort discards the temporary file, leaving path untouched.
+func (a *atomicFile) Abort() {
+	a.Close()
+	os.Remove(a.Name())
+}
+
+var output_file *atomicFile
+
+func openOutput(path string) {
+	if path == "" || path == "-" {
+		return
+	}
+	f, err := createAtomic(path)
+	checkErr(err)
+	log.Println("writing output to", f.Name())
+	output_file = f
+	output = f
+}
+
+func closeOutput() {
+	if output_file == nil {
+		return
+	}
+	f := output_file
+	output_file = nil
+	output = os.Stdout
+	checkErr(f.Commit())
+	log.Println("wrote output to", f.path)
+}
+
+// displayPath returns path as it should appear in output: unchanged, or with
+// -hash-paths the start of its SHA-256, which is the same across runs, so
+// that reports can be compared without revealing the paths themselves.
+func displayPath(path string) string {
+	if !output_hash_paths || path == "." {
+		return path
+	}
+	sum := sha256.Sum256([]byte(filepath.ToSlash(path)))
+	return hex.EncodeToString(sum[:6])
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCreateAtomicMode(t *testing.T) {
+	dir := t.TempDir()
+
+	// what the umask leaves of 0666 for any new file
+	ref, err := os.Create(filepath.Join(dir, "ref"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref.Close()
+	fi, err := os.Stat(ref.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := fi.Mode().Perm()
+
+	path := filepath.Join(dir, "results.json")
+	if got := commitAtomic(t, path); got != want {
+		t.Errorf("new file has mode %v, want %v", got, want)
+	}
+
+	if err := os.Chmod(path, 0640); err != nil {
+		t.Fatal(err)
+	}
+	if got := commitAtomic(t, path); got != 0640 {
+		t.Errorf("replaced file has mode %v, want its previous mode %v", got, os.FileMode(0640))
+	}
+}
+
+// commitAtomic writes path with createAtomic, returning its mode
+func commitAtomic(t *testing.T, path string) os.FileMode {
+	t.Helper()
+	f, err := createAtomic(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString("{}\n"); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fi.Mode().Perm()
+}
+
+func TestOutputAbort(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "results.json")
+	if err := os.WriteFile(path, []byte("previous\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// an error half way through writing, as handled by checkErr
+	openOutput(path)
+	fmt.Fprint(output, `{"languages": [`)
+	output_file.Abort()
+	output_file, output = nil, os.Stdout
+	assertOnlyFile(t, dir, "previous\n")
+
+	openOutput(path)
+	fmt.Fprint(output, "{}\n")
+	closeOutput()
+	assertOnlyFile(t, dir, "{}\n")
+
+	// l itself, failing after -o was opened
+	if _, err := runL(t, dir, "-o", path, "-git", "-git-tree", "no-such-ref"); err == nil {
+		t.Fatal("l -git-tree no-such-ref: no error")
+	}
+	assertOnlyFile(t, dir, "{}\n")
+}
+
+// assertOnlyFile checks that results.json is the only file in dir, with the
+// given contents, without temporary files left behind
+func assertOnlyFile(t *testing.T, dir, contents string) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "results.json" {
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		t.Errorf("files left: %v, want results.json only", names)
+	}
+	got, err := os.ReadFile(filepath.Join(dir, "results.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != contents {
+		t.Errorf("results.json = %q, want %q", got, contents)
+	}
+}
+
+func TestHashPaths(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"internal/secret/main.go": "package main\n",
+		"internal/tool.py":        "x = 1\n",
+	})
+	hashed := func(path string) string {
+		sum := sha256.Sum256([]byte(path))
+		return hex.EncodeToString(sum[:6])
+	}
+
+	results := runJSON(t, dir, "-fs", "-hash-paths", "-examples", "1")
+	if got, want := results["Go"].Examples, []string{hashed("internal/secret/main.go")}; !reflect.DeepEqual(got, want) {
+		t.Errorf("-examples: got %v, want %v", got, want)
+	}
+	if got, want := results["Python"].Examples, []string{hashed("internal/tool.py")}; !reflect.DeepEqual(got, want) {
+		t.Errorf("-examples: got %v, want %v", got, want)
+	}
+
+	for _, args := range [][]string{
+		{"-fs", "-hash-paths", "-list-files"},
+		{"-fs", "-hash-paths", "-dot"},
+	} {
+		out := mustRunL(t, dir, args...)
+		if strings.Contains(out, "internal") || strings.Contains(out, "secret") {
+			t.Errorf("%v: paths leaked:\n%s", args, out)
+		}
+		// the same paths always get the same hashes
+		if again := mustRunL(t, dir, args...); again != out {
+			t.Errorf("%v: got\n%s\nthen\n%s", args, out, again)
+		}
+	}
+}
+
+func TestNDJSON(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"main.go":       "package main\n",
+		"lib/util.py":   "x = 1\n",
+		"lib/\"q\".rb":  "puts 1\n",
+		"vendor/lib.js": "var x;\n",
+	})
+	out := mustRunL(t, dir, "-fs", "-ndjson", "-threads-cpu", "4")
+	got := map[string]fileResult{}
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\n"), "\n") {
+		var f fileResult
+		if err := json.Unmarshal([]byte(line), &f); err != nil {
+			t.Errorf("-ndjson: line %q: %v", line, err)
+			continue
+		}
+		got[f.Path] = f
+	}
+	// in any order, without ignored files
+	want := map[string]fileResult{
+		"main.go":     {"main.go", "Go", 13},
+		"lib/util.py": {"lib/util.py", "Python", 6},
+		`lib/"q".rb`:  {`lib/"q".rb`, "Ruby", 7},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("-ndjson: got %v, want %v", got, want)
+	}
+}
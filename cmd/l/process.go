@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/dayvonjersen/linguist"
+)
+
+// used to classify files, logging to the standard logger with -debug
+var detector = &linguist.Detector{}
+
+// the patterns given with -test-pattern, replacing linguist.IsTest
+var test_patterns []*regexp.Regexp
+
+// scanOptions returns the Options for the flags shared by git and fs mode,
+// on top of options, for a linguist.Scanner recording every file it finds
+// with recordFile.
+//
+// Files are ignored, in order: if matched by .gitignore or -ignore-file;
+// if export-ignore with -respect-export-ignore; if a test file with
+// -exclude-tests;
+// by name, as vendored or documentation, unless -unignore-filenames; or by
+// contents, as binary or generated, unless -unignore-contents. Attributes from
+// .gitattributes may override the latter two.
+func scanOptions(options linguist.Options) linguist.Options {
+	options.Detector = detector
+	options.RespectExportIgnore = input_export_ignore
+	options.ExcludeTests = input_exclude_tests
+	options.TestPatterns = test_patterns
+	options.UnignoreFilenames = unignore_filenames
+	options.UnignoreContents = unignore_contents
+	options.CountGenerated = input_count_generated
+	options.IgnoreEmpty = input_skip_empty
+	options.MaxDepth = input_max_depth
+	options.MaxFiles = input_max_files
+	options.ReadThreads = input_threads_io
+	options.Threads = cpuThreads(input_threads_cpu)
+	options.ReadBuffer = input_read_buffer
+	options.ClassifyBuffer = input_classify_buffer
+	options.Timeout = input_file_timeout
+	options.CapFileSize = output_cap_file_size
+	options.SplitEmbedded = output_split_embedded
+	options.SplitFrontMatter = output_frontmatter
+	if output_list_files {
+		options.List = func(path string) {
+			fmt.Fprintln(output, displayPath(path))
+		}
+	}
+	options.Report = recordFile
+	return options
+}
+
+// recordFile notes a file found by the Scanner for the output beyond its
+// Result, such as the examples and the largest files. The Scanner reports one
+// file at a time, so there is no need for locking.
+func recordFile(f linguist.ScannedFile) {
+	if f.IgnoreReason != "" {
+		if f.ThirdParty {
+			putSplit(true, f.Size)
+		}
+		ignored_size += f.Size
+		return
+	}
+	if f.Language == "" {
+		return
+	}
+	putSplit(f.ThirdParty, f.Size)
+
+	if output_report_eol {
+		if eol := linguist.LineEndings(f.Head); eol != "" {
+			eol_counts[eol]++
+		}
+	}
+	// not dotfiles without an extension, such as .vimrc
+	if ext := filepath.Ext(f.Path); ext != "" && ext != filepath.Base(f.Path) {
+		extensions[strings.ToLower(ext)] = true
+	}
+	if output_ndjson {
+		json_bytes, err := json.Marshal(fileResult{displayPath(f.Path), f.Language, f.Counted[f.Language]})
+		checkErr(err)
+		fmt.Fprintln(output, string(json_bytes))
+	}
+	for language, size := range f.Counted {
+		// the largest file is of the whole file, whatever -cap-file-size
+		if f.Sizes != nil {
+			putLanguage(language, f.Path, size, f.Sizes[language])
+		} else {
+			putLanguage(language, f.Path, size, f.Size)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestUnignore(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"main.go":       "package main\n",
+		"vendor/lib.rb": "puts 1\n",
+		"docs/guide.md": "# Guide\n",
+		"api.pb.go":     "// Code generated by protoc-gen-go. DO NOT EDIT.\npackage api\n",
+	})
+	for _, tt := range []struct {
+		flag     string
+		goFiles  int
+		ruby, md bool
+	}{
+		{"-fs", 1, false, false},
+		{"-unignore-filenames", 1, true, true},
+		{"-unignore-contents", 2, false, false},
+	} {
+		results := runJSON(t, dir, "-fs", tt.flag)
+		if results["Go"].Files != tt.goFiles || (results["Ruby"] != nil) != tt.ruby || (results["Markdown"] != nil) != tt.md {
+			t.Errorf("%s: got %v, want %d Go files, Ruby %v and Markdown %v", tt.flag, results, tt.goFiles, tt.ruby, tt.md)
+		}
+	}
+	if results := runJSON(t, dir, "-fs", "-unignore-filenames", "-unignore-contents"); results["Go"].Files != 2 || results["Ruby"] == nil {
+		t.Errorf("both: got %v, want everything", results)
+	}
+}
+
+func TestSplitEmbedded(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"index.html": "<html><script>var x = 1;</script><style>p {}</style></html>\n",
+	})
+	results := runJSON(t, dir, "-fs", "-split-embedded")
+	if len(results) != 3 || results["JavaScript"].Size != 10 || results["CSS"].Size != 4 || results["HTML"].Size != 46 {
+		t.Errorf("got %v, want 10 bytes of JavaScript, 4 of CSS and 46 of HTML", results)
+	}
+	// the file itself only counts towards HTML
+	if results["HTML"].Files != 1 || results["JavaScript"].Files != 0 {
+		t.Errorf("files: got %v, want one HTML file", results)
+	}
+	if results := runJSON(t, dir, "-fs"); len(results) != 1 || results["HTML"].Size != 60 {
+		t.Errorf("without -split-embedded: got %v, want 60 bytes of HTML", results)
+	}
+}
+
+func TestListFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		".git/HEAD":     "ref: refs/heads/main\n",
+		".gitignore":    "*.log\n",
+		"main.go":       "package main\n",
+		"lib/util.py":   "x = 1\n",
+		"vendor/lib.rb": "puts 1\n",
+		"docs/guide.md": "# Guide\n",
+		"debug.log":     "log\n",
+		"empty.go":      "",
+		"main_test.go":  "package main\n",
+	})
+	out := mustRunL(t, dir, "-fs", "-list-files", "-exclude-tests")
+	got := strings.Fields(out)
+	sort.Strings(got)
+	// contents are not read, so e.g. generated files would be listed too
+	want := []string{"lib/util.py", "main.go"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("-list-files: got %v, want %v", got, want)
+	}
+}
+
+func TestReportEOL(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"unix.go":       "package main\n\nfunc main() {}\n",
+		"lib.go":        "package main\n",
+		"windows.py":    "x = 1\r\ny = 2\r\n",
+		"mac.rb":        "puts 1\rputs 2\r",
+		"mixed.c":       "int x;\r\nint y;\n",
+		"oneline.txt":   "no line break",
+		"vendor/dos.js": "x = 1;\r\n",
+	})
+	out := mustRunL(t, dir, "-fs", "-report-eol")
+	// ignored files and files without line breaks are not counted
+	if want := "line endings: 2 LF, 1 CRLF, 1 CR, 1 mixed\n"; !strings.HasSuffix(out, want) {
+		t.Errorf("-report-eol: got\n%s\nwant it to end with %q", out, want)
+	}
+	if out := mustRunL(t, dir, "-fs"); strings.Contains(out, "line endings") {
+		t.Errorf("without -report-eol: got\n%s", out)
+	}
+}
+
+func TestSkipEmpty(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"main.go":     "package main\n",
+		"empty.go":    "",
+		"__init__.py": "",
+	})
+	for _, args := range [][]string{{"-fs"}, {"-fs", "-skip-empty"}} {
+		if results := runJSON(t, dir, args...); len(results) != 1 || results["Go"].Files != 1 {
+			t.Errorf("%v: got %v, want one Go file", args, results)
+		}
+	}
+	for _, tt := range []struct {
+		args    []string
+		ignored float64
+	}{
+		{[]string{"-fs", "-totals"}, 0},
+		{[]string{"-fs", "-totals", "-skip-empty"}, 2},
+	} {
+		out := mustRunL(t, dir, tt.args...)
+		var totals map[string]float64
+		if err := json.Unmarshal([]byte(out), &totals); err != nil {
+			t.Fatalf("%v: %v\n%s", tt.args, err, out)
+		}
+		if totals["total_files"] != 1 || totals["ignored_paths"] != tt.ignored {
+			t.Errorf("%v: got %v, want 1 file and %v ignored", tt.args, totals, tt.ignored)
+		}
+	}
+}
+
+func TestLockfiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"main.go":           "package main\n",
+		"package-lock.json": "{}\n",
+		"web/yarn.lock":     "# yarn lockfile v1\n",
+		"Cargo.lock":        "version = 3\n",
+		"poetry.lock":       "[[package]]\n",
+		"go.sum":            "golang.org/x/text v0.3.0 h1:abc=\n",
+	})
+	if results := runJSON(t, dir, "-fs"); len(results) != 1 || results["Go"] == nil {
+		t.Errorf("-fs: got %v, want Go only", results)
+	}
+	results := runJSON(t, dir, "-fs", "-count-generated")
+	for language, files := range map[string]int{"Go": 1, "JSON": 1, "YAML": 1, "TOML": 2, "Go Checksums": 1} {
+		if results[language] == nil || results[language].Files != files {
+			t.Errorf("-count-generated: %s: got %v, want %d files", language, results[language], files)
+		}
+	}
+}
+
+func TestVendorPattern(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"main.go":        "package main\n",
+		"sdk/client.go":  "package sdk\n",
+		"gen/api.py":     "x = 1\n",
+		"src/sdk/lib.rb": "puts 1\n",
+	})
+	results := runJSON(t, dir, "-fs", "-vendor-pattern", "^sdk/", "-vendor-pattern", `^gen/.*\.py$`)
+	if len(results) != 2 || results["Go"].Files != 1 || results["Ruby"] == nil {
+		t.Errorf("-vendor-pattern: got %v, want main.go and src/sdk/lib.rb", results)
+	}
+	if results := runJSON(t, dir, "-fs", "-vendor-pattern", "^sdk/", "-unignore-filenames"); results["Go"].Files != 2 {
+		t.Errorf("-vendor-pattern -unignore-filenames: got %v, want 2 Go files", results)
+	}
+	if out, err := runL(t, dir, "-fs", "-vendor-pattern", "("); err == nil {
+		t.Errorf("invalid -vendor-pattern: got\n%s\nwant an error", out)
+	}
+}
+
+func TestWorkflows(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		".github/workflows/ci.yml":       "on: push\njobs: {}\n",
+		".github/workflows/release.yaml": "on: release\n",
+		".github/dependabot.yml":         "version: 2\n",
+		"sub/.github/workflows/ci.yml":   "on: push\n",
+	})
+	// only the top-level workflows, other .github files are still vendored
+	results := runJSON(t, dir, "-fs")
+	if len(results) != 1 || results["YAML"].Files != 2 {
+		t.Errorf("got %v, want 2 YAML files", results)
+	}
+}
+
+func TestExcludeTests(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"main.go":          "package main\n",
+		"main_test.go":     "package main\n",
+		"app.py":           "x = 1\n",
+		"test_app.py":      "x = 1\n",
+		"src/app.ts":       "let x = 1;\n",
+		"src/app.spec.ts":  "let x = 1;\n",
+		"src/App.java":     "class App {}\n",
+		"src/AppTest.java": "class AppTest {}\n",
+		"spec/helper.rb":   "puts 1\n",
+		"e2e/smoke.go":     "package e2e\n",
+	})
+	out := mustRunL(t, dir, "-fs", "-list-files", "-exclude-tests")
+	got := strings.Fields(out)
+	sort.Strings(got)
+	want := []string{"app.py", "e2e/smoke.go", "main.go", "src/App.java", "src/app.ts"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("-exclude-tests: got %v, want %v", got, want)
+	}
+	// test files count as ignored
+	results := runJSON(t, dir, "-fs", "-exclude-tests")
+	if results["Ruby"] != nil || results["Go"] == nil || results["Go"].Files != 2 {
+		t.Errorf("-exclude-tests: got %v, want no Ruby and 2 Go files", results)
+	}
+
+	// the conventions are replaced by the patterns given
+	out = mustRunL(t, dir, "-fs", "-list-files", "-exclude-tests", "-test-pattern", `^e2e/`, "-test-pattern", `_test\.go$`)
+	got = strings.Fields(out)
+	sort.Strings(got)
+	want = []string{"app.py", "main.go", "spec/helper.rb", "src/App.java", "src/AppTest.java", "src/app.spec.ts", "src/app.ts", "test_app.py"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("-test-pattern: got %v, want %v", got, want)
+	}
+	if _, err := runL(t, dir, "-fs", "-exclude-tests", "-test-pattern", "(unclosed"); err == nil {
+		t.Error("invalid -test-pattern: want an error")
+	}
+}
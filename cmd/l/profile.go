@@ -0,0 +1,47 @@
+package main
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+)
+
+// startProfiles starts writing a CPU profile for -cpuprofile, and returns a
+// function to call once the scan is done, which stops it and writes a heap
+// profile for -memprofile.
+//
+// Both paths are resolved before findGitDir() changes the working directory.
+func startProfiles() (stop func()) {
+	var cpu *os.File
+	if input_cpuprofile != "" {
+		path, err := filepath.Abs(input_cpuprofile)
+		checkErr(err)
+		cpu, err = os.Create(path)
+		checkErr(err)
+		checkErr(pprof.StartCPUProfile(cpu))
+		log.Println("writing CPU profile to", path)
+	}
+	mem := ""
+	if input_memprofile != "" {
+		path, err := filepath.Abs(input_memprofile)
+		checkErr(err)
+		mem = path
+	}
+	return func() {
+		if cpu != nil {
+			pprof.StopCPUProfile()
+			checkErr(cpu.Close())
+		}
+		if mem != "" {
+			f, err := os.Create(mem)
+			checkErr(err)
+			// up to date statistics of what is still allocated
+			runtime.GC()
+			checkErr(pprof.WriteHeapProfile(f))
+			checkErr(f.Close())
+			log.Println("wrote heap profile to", mem)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"log"
+	"math"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dayvonjersen/git4go"
+)
+
+// the age at which a file counts for half its size with -recency-weighted
+const recencyHalfLife = 180 * 24 * time.Hour
+
+// the most recent commit changing a path
+type lastCommit struct {
+	when   time.Time
+	author string
+}
+
+var (
+	// the last commit modifying each path, see loadLastModified
+	last_commits = map[string]lastCommit{}
+
+	// the time of the commit being scanned, ages are relative to it
+	recency_now time.Time
+)
+
+// loadLastModified walks the first-parent history of commit_id, recording for
+// every path the committer time and author of the most recent commit which
+// changed it, for -recency-weighted and -by-author.
+func loadLastModified(repo *git4go.Repository, odb *git4go.Odb, commit_id *git4go.Oid) {
+	for id := commit_id; id != nil; {
+		commit, err := repo.LookupCommit(id)
+		checkErr(err)
+		when := commit.Committer().When
+		author := commit.Author().Name + " <" + commit.Author().Email + ">"
+		if id == commit_id {
+			recency_now = when
+		}
+		tree, err := commit.Tree()
+		checkErr(err)
+		var parent_tree *git4go.Tree
+		id = firstParent(odb, id)
+		if id != nil {
+			parent_tree = lookupTree(repo, id)
+		}
+		diffTrees(repo, tree, parent_tree, []string{}, func(path string) {
+			if _, ok := last_commits[path]; !ok {
+				last_commits[path] = lastCommit{when, author}
+			}
+		})
+	}
+	log.Println("found last commits for", len(last_commits), "paths")
+}
+
+// firstParent returns the first parent of commit_id, or nil for a root commit.
+func firstParent(odb *git4go.Odb, commit_id *git4go.Oid) *git4go.Oid {
+	return nthParent(odb, commit_id, 1)
+}
+
+// nthParent returns the nth parent of commit_id, counting from 1, or nil if
+// it has fewer, e.g. for the second parent of a commit which is no merge.
+//
+// git4go does not parse the parents of commits, so they are read from the
+// header of the raw object.
+func nthParent(odb *git4go.Odb, commit_id *git4go.Oid, n int) *git4go.Oid {
+	obj, err := odb.Read(commit_id)
+	checkErr(err)
+	if obj.Type != git4go.ObjectCommit {
+		return nil
+	}
+	for _, line := range strings.Split(string(obj.Data), "\n") {
+		if line == "" {
+			break
+		}
+		if strings.HasPrefix(line, "parent ") {
+			if n--; n > 0 {
+				continue
+			}
+			oid, err := git4go.NewOid(strings.TrimPrefix(line, "parent "))
+			checkErr(err)
+			return oid
+		}
+	}
+	return nil
+}
+
+// diffTrees calls changed for the path of every blob in tree which is not
+// identical in old, or every blob if old is nil.
+func diffTrees(repo *git4go.Repository, tree, old *git4go.Tree, parent []string, changed func(path string)) {
+	for _, entry := range tree.Entries {
+		var old_entry *git4go.TreeEntry
+		if old != nil {
+			old_entry = old.EntryByName(entry.Name)
+			if old_entry != nil && old_entry.Id.Equal(entry.Id) {
+				continue
+			}
+		}
+		switch entry.Type {
+		case git4go.ObjectTree:
+			var old_tree *git4go.Tree
+			if old_entry != nil && old_entry.Type == git4go.ObjectTree {
+				old_tree = lookupTree(repo, old_entry.Id)
+			}
+			diffTrees(repo, lookupTree(repo, entry.Id), old_tree, append(parent, entry.Name), changed)
+		case git4go.ObjectBlob:
+			changed(filepath.Join(append(parent, entry.Name)...))
+		}
+	}
+}
+
+// recencyWeight returns the factor by which the size of path is multiplied
+// with -recency-weighted, halving every recencyHalfLife since it was last
+// modified.
+func recencyWeight(path string) float64 {
+	last, ok := last_commits[path]
+	if !ok {
+		return 1
+	}
+	age := recency_now.Sub(last.when)
+	if age < 0 {
+		age = 0
+	}
+	return math.Pow(0.5, float64(age)/float64(recencyHalfLife))
+}
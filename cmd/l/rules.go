@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+
+	"gopkg.in/yaml.v1"
+)
+
+// loadRules adds the rules from a -rules file to detector, e.g.
+//
+//   - pattern: '(?m)^# ACME build script'
+//     language: Python
+//
+// Rules are tried in order and the first matching pattern wins.
+func loadRules(filename string) {
+	data, err := ioutil.ReadFile(filename)
+	checkErr(err)
+	var rules []struct {
+		Pattern  string `yaml:"pattern"`
+		Language string `yaml:"language"`
+	}
+	checkErr(yaml.Unmarshal(data, &rules))
+	for i, r := range rules {
+		if r.Pattern == "" || r.Language == "" {
+			checkErr(fmt.Errorf("%s: rule %d needs both a pattern and a language", filename, i+1))
+		}
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			checkErr(fmt.Errorf("%s: rule %d: %v", filename, i+1, err))
+		}
+		detector.AddRule(re, r.Language)
+	}
+}
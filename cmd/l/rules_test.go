@@ -0,0 +1,24 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestRules(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"rules.yml":  "- pattern: '(?m)^// ACME generated banner'\n  language: Python\n",
+		"src/job.js": "// ACME generated banner\nrun();\n",
+		"src/app.js": "run();\n",
+	})
+	results := runJSON(t, filepath.Join(dir, "src"), "-fs", "-rules", filepath.Join(dir, "rules.yml"))
+	if results["Python"] == nil || results["Python"].Files != 1 || results["JavaScript"] == nil || results["JavaScript"].Files != 1 {
+		t.Errorf("got %v, want job.js as Python and app.js as JavaScript", results)
+	}
+
+	writeFiles(t, dir, map[string]string{"bad.yml": "- pattern: '(?m)^// ACME'\n"})
+	if _, err := runL(t, dir, "-fs", "-rules", filepath.Join(dir, "bad.yml")); err == nil {
+		t.Error("-rules with a rule without a language: no error")
+	}
+}
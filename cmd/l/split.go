@@ -0,0 +1,45 @@
+package main
+
+import "fmt"
+
+// the bytes and number of files on one side of -summary-split
+type splitTotals struct {
+	Size  int `json:"size"`
+	Files int `json:"files"`
+}
+
+// third party files are those vendored or generated, whether or not they
+// were ignored, and first party files all others which were counted
+var first_party, third_party splitTotals
+
+// putSplit counts a file of size bytes towards one side of -summary-split
+func putSplit(is_third_party bool, size int) {
+	side := &first_party
+	if is_third_party {
+		side = &third_party
+	}
+	side.Size += size
+	side.Files++
+}
+
+// writeSummarySplit writes the first and third party totals, and the share
+// of first party bytes
+func writeSummarySplit() {
+	ratio, third_ratio := 0.0, 0.0
+	if total := first_party.Size + third_party.Size; total > 0 {
+		ratio = float64(first_party.Size) / float64(total)
+		third_ratio = 1 - ratio
+	}
+	if output_json {
+		json_bytes, err := marshalJSON(struct {
+			FirstParty splitTotals `json:"first_party"`
+			ThirdParty splitTotals `json:"third_party"`
+			Ratio      float64     `json:"first_party_ratio"`
+		}{first_party, third_party, ratio})
+		checkErr(err)
+		fmt.Fprintln(output, string(json_bytes))
+		return
+	}
+	fmt.Fprintf(output, "first-party: %s in %d file%s (%.2f%%)\n", humanizeBytes(first_party.Size), first_party.Files, pluralize(first_party.Files), ratio*100)
+	fmt.Fprintf(output, "third-party: %s in %d file%s (%.2f%%)\n", humanizeBytes(third_party.Size), third_party.Files, pluralize(third_party.Files), third_ratio*100)
+}
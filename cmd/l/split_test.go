@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestSummarySplit(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"main.go":             "package main\n",
+		"lib/util.py":         "x = 1\n",
+		"vendor/dep/dep.go":   "package dep\n",
+		"node_modules/a/a.js": "module.exports = 1;\n",
+		"api.pb.go":           "// Code generated by protoc-gen-go. DO NOT EDIT.\npackage api\n",
+		"docs/guide.md":       "# Guide\n",
+		"logo.png":            "\x89PNG\r\n\x1a\n\x01\x02",
+	})
+	out := mustRunL(t, dir, "-fs", "-summary-split", "-json")
+	var got struct {
+		FirstParty splitTotals `json:"first_party"`
+		ThirdParty splitTotals `json:"third_party"`
+		Ratio      float64     `json:"first_party_ratio"`
+	}
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("-summary-split -json: %v\n%s", err, out)
+	}
+	// documentation and binary files count towards neither
+	first := splitTotals{13 + 6, 2}
+	third := splitTotals{12 + 20 + 61, 3}
+	if got.FirstParty != first || got.ThirdParty != third {
+		t.Errorf("got %+v and %+v, want %+v and %+v", got.FirstParty, got.ThirdParty, first, third)
+	}
+	if want := 19.0 / (19 + 93); got.Ratio != want {
+		t.Errorf("first_party_ratio = %v, want %v", got.Ratio, want)
+	}
+
+	out = mustRunL(t, dir, "-fs", "-summary-split")
+	want := "first-party: 19 B in 2 files (16.96%)\nthird-party: 93 B in 3 files (83.04%)\n"
+	if !strings.HasPrefix(out, want) {
+		t.Errorf("-summary-split: got\n%s\nwant\n%s", out, want)
+	}
+}
+
+func TestSplitFrontMatter(t *testing.T) {
+	dir := t.TempDir()
+	front := "---\nlayout: post\ntitle: Hello\n---\n"
+	body := "# Hello\n\nThe first post.\n"
+	writeFiles(t, dir, map[string]string{
+		"_posts/2020-01-01-hello.md": front + body,
+		"_posts/draft.md":            "---\nlayout: post\n# Unclosed\n",
+		"main.go":                    "package main\n",
+	})
+	results := runJSON(t, dir, "-fs", "-split-frontmatter")
+	unclosed := len("---\nlayout: post\n# Unclosed\n")
+	if l := results["YAML"]; l == nil || l.Size != len(front) {
+		t.Errorf("YAML: got %+v, want %d bytes", l, len(front))
+	}
+	if l := results["Markdown"]; l == nil || l.Size != len(body)+unclosed || l.Files != 2 {
+		t.Errorf("Markdown: got %+v, want %d bytes in 2 files", l, len(body)+unclosed)
+	}
+	if l := results["Go"]; l == nil || l.Size != 13 {
+		t.Errorf("Go: got %+v, want 13 bytes", l)
+	}
+
+	// without the flag, all of a post is Markdown
+	results = runJSON(t, dir, "-fs")
+	if l := results["Markdown"]; l == nil || l.Size != len(front)+len(body)+unclosed || results["YAML"] != nil {
+		t.Errorf("without -split-frontmatter: got %v, want only Markdown and Go", results)
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/dayvonjersen/linguist"
+)
+
+// an entry of "languages" with -stats-json
+type languageStats struct {
+	Size    int     `json:"size"`
+	Percent float64 `json:"percent"`
+	Color   string  `json:"color"` // see -color-format
+	Type    string  `json:"type"`  // see linguist.LanguageType
+}
+
+// the output of -stats-json
+type statsJSON struct {
+	Languages map[string]languageStats `json:"languages"`
+	Meta      struct {
+		totals
+		metadata // see -record-invocation and -record-commit
+	} `json:"meta"`
+}
+
+// writeStatsJSON writes results, shown for r, keyed by language as expected
+// by web frontends, along with the totals
+func writeStatsJSON(r linguist.Result, results []*language) {
+	var stats statsJSON
+	stats.Languages = map[string]languageStats{}
+	for _, l := range results {
+		stats.Languages[l.Language] = languageStats{
+			Size:    l.Size,
+			Percent: l.Percent,
+			Color:   formattedColor(l.Language),
+			Type:    linguist.LanguageType(l.Language),
+		}
+	}
+	stats.Meta.totals = totalsOf(r)
+	stats.Meta.metadata, _ = recordedMetadata()
+	json_bytes, err := marshalJSON(stats)
+	checkErr(err)
+	fmt.Fprintln(output, string(json_bytes))
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestStatsJSON(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"main.go":   "package main\n",
+		"README.md": "# Title\n\nSome text.\n",
+	})
+	out := mustRunL(t, dir, "-fs", "-stats-json", "-unignore-filenames")
+	var got map[string]map[string]interface{}
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("-stats-json: %v\n%s", err, out)
+	}
+	if len(got) != 2 || got["languages"] == nil || got["meta"] == nil {
+		t.Fatalf("-stats-json: got %v, want languages and meta", got)
+	}
+
+	for name, want := range map[string]map[string]interface{}{
+		"Go":       {"size": float64(13), "color": "#00ADD8", "type": "programming"},
+		"Markdown": {"size": float64(20), "color": "#083fa1", "type": "prose"},
+	} {
+		l, ok := got["languages"][name].(map[string]interface{})
+		if !ok {
+			t.Errorf("-stats-json: no %s in %v", name, got["languages"])
+			continue
+		}
+		var keys []string
+		for k := range l {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		if want := []string{"color", "percent", "size", "type"}; !reflect.DeepEqual(keys, want) {
+			t.Errorf("-stats-json: %s has %v, want %v", name, keys, want)
+		}
+		if percent := l["percent"]; percent != want["size"].(float64)/33*100 {
+			t.Errorf("-stats-json: %s at %v%%, want its share of the total", name, percent)
+		}
+		delete(l, "percent")
+		if !reflect.DeepEqual(l, want) {
+			t.Errorf("-stats-json: %s: got %v, want %v", name, l, want)
+		}
+	}
+
+	want := map[string]interface{}{
+		"total_size":      float64(33),
+		"total_files":     float64(2),
+		"total_languages": float64(2),
+		"ignored_paths":   float64(0),
+	}
+	if !reflect.DeepEqual(got["meta"], want) {
+		t.Errorf("-stats-json: meta: got %v, want %v", got["meta"], want)
+	}
+}
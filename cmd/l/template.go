@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/template"
+
+	"github.com/dayvonjersen/linguist"
+)
+
+// the parsed -template, nil if not given
+var output_tmpl *template.Template
+
+// functions available to -template
+var templateFuncs = template.FuncMap{
+	"color": formattedColor,
+	"bytes": humanizeBytes,
+}
+
+// the data -template is executed with
+type templateContext struct {
+	// the results, largest first, as shown by default
+	Languages []*language
+
+	Totals totals
+}
+
+// parseTemplate parses -template, exiting with an error message if it is
+// invalid, before any files are scanned
+func parseTemplate(text string) {
+	tmpl, err := template.New("-template").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "invalid -template:", err)
+		os.Exit(1)
+	}
+	output_tmpl = tmpl
+}
+
+// writeTemplate renders results, shown for r, with -template
+func writeTemplate(r linguist.Result, results []*language) {
+	checkErr(output_tmpl.Execute(output, templateContext{
+		Languages: results,
+		Totals:    totalsOf(r),
+	}))
+}
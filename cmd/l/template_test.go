@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/dayvonjersen/linguist"
+)
+
+func TestWriteTemplate(t *testing.T) {
+	r := linguist.NewResult(map[string]int{"Go": 3000, "YAML": 1000}, map[string]int{"Go": 3, "YAML": 1}, 2)
+	var results []*language
+	for _, stat := range r.Languages {
+		results = append(results, &language{Language: stat.Name, Percent: stat.Percent, Percentage: fmt.Sprintf("%.2f", stat.Percent), Size: stat.Size})
+	}
+	parseTemplate(`{{range .Languages}}{{.Language}} {{color .Language}}: {{.Percentage}}% ({{bytes .Size}}){{"\n"}}{{end}}` +
+		`{{.Totals.TotalLanguages}} languages, {{.Totals.TotalFiles}} files, {{.Totals.IgnoredPaths}} ignored{{"\n"}}`)
+	var buf bytes.Buffer
+	output = &buf
+	writeTemplate(r, results)
+	want := "Go #00ADD8: 75.00% (2.9 KiB)\n" +
+		"YAML #cb171e: 25.00% (1000 B)\n" +
+		"2 languages, 4 files, 2 ignored\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestTemplateInvalid(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"main.go": "package main\n"})
+	out, err := runL(t, dir, "-fs", "-template", "{{range .Languages}")
+	if err == nil || out != "" {
+		t.Errorf("invalid -template: got %q, %v, want an error and no output", out, err)
+	}
+	if out := mustRunL(t, dir, "-fs", "-template", "{{len .Languages}}"); strings.TrimSpace(out) != "1" {
+		t.Errorf("-template: got %q, want 1", out)
+	}
+}
@@ -0,0 +1,177 @@
+package linguist
+
+import "regexp"
+
+// Logger is used by Detector for debug messages, and is satisfied by
+// *log.Logger from the standard library.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// A Detector determines the language of files, reporting how it did so
+// to its Logger.
+//
+// The first of these to give a language wins: rules added with AddRule, a
+// linguist:language= comment, an editor modeline, sniffing contents in
+// content priority mode, the filename, the extension and then the contents
+// (see Detect). Unlike in earlier versions, which started with the filename,
+// the first four may override it. Scanner checks linguist-language attributes
+// before all of them.
+//
+// The zero value is ready to use.
+type Detector struct {
+	// Receives debug messages, which are discarded if nil.
+	Logger Logger
+
+	// In content priority mode, contents are checked for certain kinds of
+	// data (e.g. CSV) before a file's name, which would otherwise win.
+	ContentPriority bool
+
+	// Limits for the classifier (see Analyse), for trading accuracy for
+	// speed or certainty: it only considers the first MaxTokens tokens of
+	// contents, and only chooses among the MaxCandidates languages scoring
+	// best, so that a file is left undetected if none of them is among its
+	// hints. Unlimited if 0, as in github linguist.
+	MaxCandidates int
+	MaxTokens     int
+
+	// If not nil, reports whether path is excluded by a .gitignore file, for
+	// IgnoreReason and FilenameIgnoreReason. A Scanner checks its own
+	// Options.IgnoreFiles regardless.
+	IsGitIgnored func(path string) bool
+
+	rules          []heuristic
+	vendorPatterns []*regexp.Regexp
+	postProcessors []func(FileInfo) FileInfo
+}
+
+// AddRule makes Detect report language for any file whose contents match
+// pattern, regardless of its name.
+//
+// Rules are tried in the order they were added, before any other strategy,
+// and the first match wins.
+func (d *Detector) AddRule(pattern *regexp.Regexp, language string) {
+	d.rules = append(d.rules, heuristic{language, pattern, nil})
+}
+
+// AddVendorPattern makes IsVendored report any path matching re, in
+// addition to those from vendor.yml, e.g. for code copied in by tooling.
+func (d *Detector) AddVendorPattern(re *regexp.Regexp) {
+	d.vendorPatterns = append(d.vendorPatterns, re)
+}
+
+// Like the IsVendored function, also checking patterns added with
+// AddVendorPattern.
+func (d *Detector) IsVendored(path string) bool {
+	if IsVendored(path) {
+		return true
+	}
+	for _, re := range d.vendorPatterns {
+		if re.MatchString(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// Like the IgnoreReason function, also checking IsGitIgnored and patterns
+// added with AddVendorPattern.
+func (d *Detector) IgnoreReason(path string, contents []byte) (ignored bool, reason string) {
+	reason = d.FilenameIgnoreReason(path)
+	if reason == "" && contents != nil {
+		reason = ContentsIgnoreReason(path, contents)
+	}
+	return reason != "", reason
+}
+
+// Like the FilenameIgnoreReason function, also checking IsGitIgnored and
+// patterns added with AddVendorPattern.
+func (d *Detector) FilenameIgnoreReason(path string) string {
+	if d.IsGitIgnored != nil && d.IsGitIgnored(path) {
+		return IgnoredGitIgnore
+	}
+	reason := FilenameIgnoreReason(path)
+	if reason == "" && d.IsVendored(path) {
+		reason = IgnoredVendored
+	}
+	return reason
+}
+
+// AddPostProcessor registers fn to adjust the result of Analyze, e.g. to
+// reclassify every file below a certain directory. Post processors are
+// invoked in the order they were added, after all built in detection, each
+// given the FileInfo returned by the previous one.
+//
+// Detect and PrimaryLanguage report the outcome of post processors as well.
+// If a post processor changes Language but not Type, Type follows Language.
+func (d *Detector) AddPostProcessor(fn func(FileInfo) FileInfo) {
+	d.postProcessors = append(d.postProcessors, fn)
+}
+
+func (d *Detector) logf(format string, v ...interface{}) {
+	if d.Logger != nil {
+		d.Logger.Printf(format, v...)
+	}
+}
+
+// Attempts to determine the language of the file at path, first by any
+// rules (see AddRule), then by a linguist:language= comment at the top of
+// the file (see LanguageByComment) or an editor modeline (see
+// LanguageByModeline), in content priority mode by sniffing its
+// contents, then by its name (see LanguageByFilename) and then by
+// its contents (see LanguageHints and LanguageByContents).
+//
+// Returns the empty string if a language could not be determined.
+func (d *Detector) Detect(path string, contents []byte) string {
+	if len(d.postProcessors) > 0 {
+		return d.Analyze(path, contents).Language
+	}
+	language, _ := d.detect(path, contents)
+	return language
+}
+
+// detect is Detect, also returning one of the Strategy* constants.
+func (d *Detector) detect(path string, contents []byte) (language, strategy string) {
+	// rules and heuristics see UTF-8 with "\n" line endings only
+	contents = normalizeEOL(toUTF8(contents))
+	for _, r := range d.rules {
+		if r.pattern.Match(contents) {
+			d.logf("%s got result by rule %s: %s", path, r.pattern, r.language)
+			return r.language, StrategyRule
+		}
+	}
+
+	if language := LanguageByComment(contents); language != "" {
+		d.logf("%s got result by %s: %s", path, StrategyComment, language)
+		return language, StrategyComment
+	}
+
+	if language := LanguageByModeline(contents); language != "" {
+		d.logf("%s got result by %s: %s", path, StrategyModeline, language)
+		return language, StrategyModeline
+	}
+
+	hints := LanguageHints(path)
+
+	if d.ContentPriority {
+		if language := languageBySniffing(contents, hints); language != "" {
+			d.logf("%s got result by %s: %s", path, StrategySniffing, language)
+			return language, StrategySniffing
+		}
+	}
+
+	if language, strategy := languageByFilename(path); language != "" {
+		d.logf("%s got result by %s: %s", path, strategy, language)
+		return language, strategy
+	}
+
+	d.logf("%s got language hints: %#v", path, hints)
+
+	if language, strategy := languageByContents(contents, hints, d.MaxCandidates, d.MaxTokens); language != "" {
+		d.logf("%s got result by %s: %s", path, strategy, language)
+		return language, strategy
+	}
+
+	d.logf("%s got no result!!", path)
+	return "", ""
+}
@@ -0,0 +1,203 @@
+package linguist
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// captureLogger records the messages logged through it
+type captureLogger struct {
+	messages []string
+}
+
+func (l *captureLogger) Printf(format string, v ...interface{}) {
+	l.messages = append(l.messages, fmt.Sprintf(format, v...))
+}
+
+func TestDetectorLogger(t *testing.T) {
+	logger := &captureLogger{}
+	d := &Detector{Logger: logger}
+	if got := d.Detect("main.go", []byte("package main\n")); got != "Go" {
+		t.Fatalf("Detect = %q, want Go", got)
+	}
+	if len(logger.messages) == 0 || !strings.Contains(strings.Join(logger.messages, "\n"), "main.go got result by extension: Go") {
+		t.Errorf("logged %q, want how main.go was detected", logger.messages)
+	}
+
+	// nothing is logged without a Logger, and nothing panics either
+	if got := (&Detector{}).Detect("main.go", []byte("package main\n")); got != "Go" {
+		t.Errorf("Detect without a Logger = %q, want Go", got)
+	}
+}
+
+func TestDetectorAddRule(t *testing.T) {
+	d := &Detector{}
+	d.AddRule(regexp.MustCompile(`(?m)^# ACME build script`), "Python")
+	d.AddRule(regexp.MustCompile(`(?m)^# ACME`), "Ruby")
+	for _, tt := range []detectCase{
+		// regardless of the name, and the first matching rule wins
+		{"build.sh", "# ACME build script\nrun()\n", "Python"},
+		{"notes.txt", "# ACME notes\n", "Ruby"},
+		{"build.sh", "echo hi\n", "Shell"},
+	} {
+		if got := d.Detect(tt.filename, []byte(tt.contents)); got != tt.want {
+			t.Errorf("Detect(%q, %q) = %q, want %q", tt.filename, tt.contents, got, tt.want)
+		}
+	}
+}
+
+func TestDetectComment(t *testing.T) {
+	d := &Detector{}
+	// the comment overrides the extension
+	contents := []byte("# linguist:language=Cython\ndef f(int x):\n    return x\n")
+	if fi := d.Analyze("fast.py", contents); fi.Language != "Cython" || fi.Strategy != StrategyComment {
+		t.Errorf("Analyze(fast.py) = %q by %s, want Cython by %s", fi.Language, fi.Strategy, StrategyComment)
+	}
+	// but not a rule
+	d.AddRule(regexp.MustCompile(`def f\(`), "Python")
+	if got := d.Detect("fast.py", contents); got != "Python" {
+		t.Errorf("Detect(fast.py) with a rule = %q, want Python", got)
+	}
+}
+
+func TestDetectorPrecedence(t *testing.T) {
+	d := &Detector{}
+	d.AddRule(regexp.MustCompile(`(?m)^# ACME$`), "Go")
+	sniffed := &Detector{ContentPriority: true}
+	csv := "a,b,c\n1,2,3\n4,5,6\n"
+	for _, tt := range []struct {
+		d                  *Detector
+		path, contents     string
+		language, strategy string
+	}{
+		{d, "build.rb", "# linguist:language=Python\n# ACME\n", "Go", StrategyRule},
+		{&Detector{}, "build.rb", "# linguist:language=Python\n# ACME\n", "Python", StrategyComment},
+		{&Detector{}, "build.rb", "# linguist:language=Python\n# vim: set ft=sh:\n", "Python", StrategyComment},
+		{&Detector{}, "build.rb", "# vim: set ft=sh:\n", "Shell", StrategyModeline},
+		{sniffed, "data.txt", "# vim: set ft=sh:\n" + csv, "Shell", StrategyModeline},
+		{sniffed, "data.txt", csv, "CSV", StrategySniffing},
+		// .txt is ambiguous, so contents decide, but among its languages
+		{&Detector{}, "data.txt", csv, "Text", StrategyClassifier},
+		{&Detector{}, "Rakefile", "#!/usr/bin/env python\n", "Ruby", StrategyFilename},
+		{&Detector{}, "build.rb", "#!/usr/bin/env python\n", "Ruby", StrategyExtension},
+		{&Detector{}, "run", "#!/usr/bin/env python\n", "Python", StrategyInterpreter},
+	} {
+		fi := tt.d.Analyze(tt.path, []byte(tt.contents))
+		if fi.Language != tt.language || fi.Strategy != tt.strategy {
+			t.Errorf("%s %q: got %s by %s, want %s by %s", tt.path, tt.contents, fi.Language, fi.Strategy, tt.language, tt.strategy)
+		}
+	}
+}
+
+func TestDetectorAddPostProcessor(t *testing.T) {
+	d := &Detector{}
+	// org-specific fixups: templates/ holds Go templates, and everything
+	// under generated/ is generated whatever its contents
+	d.AddPostProcessor(func(fi FileInfo) FileInfo {
+		if strings.HasPrefix(fi.Path, "templates/") {
+			fi.Language = "Go"
+		}
+		return fi
+	})
+	d.AddPostProcessor(func(fi FileInfo) FileInfo {
+		if strings.HasPrefix(fi.Path, "generated/") {
+			fi.IsGenerated = true
+		}
+		return fi
+	})
+
+	if got := d.Detect("templates/page.txt", []byte("{{ .Title }}\n")); got != "Go" {
+		t.Errorf("Detect = %q, want Go", got)
+	}
+	if fi := d.Analyze("templates/page.txt", []byte("{{ .Title }}\n")); fi.Language != "Go" || fi.Type != "programming" {
+		t.Errorf("Analyze = %+v, want Go with its type", fi)
+	}
+	if fi := d.Analyze("generated/api.py", []byte("x = 1\n")); !fi.IsGenerated || !fi.Ignored() {
+		t.Errorf("Analyze = %+v, want generated", fi)
+	}
+
+	// the aggregate reflects the post processors
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"templates/a.txt":  strings.Repeat("{{ .Title }}\n", 10),
+		"templates/b.txt":  strings.Repeat("{{ .Body }}\n", 10),
+		"generated/api.py": strings.Repeat("x = 1\n", 1000),
+		"tool.py":          "x = 1\n",
+	})
+	result, err := NewScanner(root, Options{Detector: d}).Scan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := result.Language("Go"); got == nil || got.Files != 2 {
+		t.Errorf("Go: got %+v, want the 2 templates", got)
+	}
+	if got := result.Language("Python"); got == nil || got.Files != 1 || result.IgnoredPaths != 1 {
+		t.Errorf("Python: got %+v and %d ignored, want tool.py only", got, result.IgnoredPaths)
+	}
+	if got, err := d.PrimaryLanguage(root); err != nil || got != "Go" {
+		t.Errorf("PrimaryLanguage = %q, %v, want Go", got, err)
+	}
+}
+
+func TestDetectorMaxCandidates(t *testing.T) {
+	// Python in a .frag file, which is JavaScript or GLSL by name: the
+	// classifier scores Python best, so only more candidates reach a hint
+	contents := []byte("import os\n\ndef main():\n    print(os.getcwd())\n\nif __name__ == '__main__':\n    main()\n")
+	for _, tt := range []struct {
+		maxCandidates int
+		want          string
+	}{
+		{1, ""},
+		{1000, "JavaScript"},
+		{0, "JavaScript"},
+	} {
+		d := &Detector{MaxCandidates: tt.maxCandidates}
+		if got := d.Detect("shader.frag", contents); got != tt.want {
+			t.Errorf("MaxCandidates %d: Detect = %q, want %q", tt.maxCandidates, got, tt.want)
+		}
+	}
+	// without hints the best score wins anyway
+	if got := (&Detector{MaxCandidates: 1}).Detect("script", contents); got != "Python" {
+		t.Errorf("MaxCandidates 1 without hints: Detect = %q, want Python", got)
+	}
+}
+
+func TestDetectorAddVendorPattern(t *testing.T) {
+	d := &Detector{}
+	d.AddVendorPattern(regexp.MustCompile(`^sdk/`))
+	d.AddVendorPattern(regexp.MustCompile(`(?:^|/)copied_[^/]*\.go$`))
+	for path, want := range map[string]bool{
+		"sdk/lib/lib.c":          true,
+		"src/sdk/lib.c":          false,
+		"pkg/copied_strings.go":  true,
+		"pkg/strings.go":         false,
+		"vendor/github.com/x.go": true,
+	} {
+		if got := d.IsVendored(path); got != want {
+			t.Errorf("IsVendored(%q) = %v, want %v", path, got, want)
+		}
+		if reason := d.FilenameIgnoreReason(path); (reason == IgnoredVendored) != want {
+			t.Errorf("FilenameIgnoreReason(%q) = %q", path, reason)
+		}
+	}
+	// the package level functions are unaffected
+	if IsVendored("sdk/lib/lib.c") {
+		t.Error("IsVendored(sdk/lib/lib.c) without the pattern")
+	}
+
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"main.go":         "package main\n",
+		"sdk/lib.go":      "package lib\n",
+		"pkg/copied_x.go": "package pkg\n",
+	})
+	result, err := NewScanner(root, Options{Detector: d}).Scan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.TotalFiles != 1 || result.IgnoredPaths != 2 {
+		t.Errorf("got %d files and %d ignored, want 1 and 2", result.TotalFiles, result.IgnoredPaths)
+	}
+}
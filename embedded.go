@@ -0,0 +1,89 @@
+package linguist
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+)
+
+var (
+	scriptRE = regexp.MustCompile(`(?is)<script\b([^>]*)>(.*?)</script\s*>`)
+	styleRE  = regexp.MustCompile(`(?is)<style\b[^>]*>(.*?)</style\s*>`)
+	typeRE   = regexp.MustCompile(`(?i)\btype\s*=\s*["']?([^"'\s>]+)`)
+)
+
+// Splits an HTML document into the number of bytes belonging to each
+// language embedded in it: the contents of <style> elements are counted as
+// CSS, those of <script> elements as JavaScript (or JSON, for e.g.
+// type="application/ld+json"), and everything else as HTML.
+//
+// This is only an approximation, as contents are not actually parsed.
+func SplitHTML(contents []byte) map[string]int {
+	sizes := map[string]int{}
+	embedded := 0
+	for _, m := range scriptRE.FindAllSubmatchIndex(contents, -1) {
+		language := "JavaScript"
+		if t := typeRE.FindSubmatch(contents[m[2]:m[3]]); t != nil {
+			switch typ := strings.ToLower(string(t[1])); {
+			case strings.Contains(typ, "json"):
+				language = "JSON"
+			case !strings.Contains(typ, "javascript") && !strings.Contains(typ, "ecmascript") && typ != "module":
+				// templates and the like are left as HTML
+				continue
+			}
+		}
+		sizes[language] += m[5] - m[4]
+		embedded += m[5] - m[4]
+	}
+	for _, m := range styleRE.FindAllSubmatchIndex(contents, -1) {
+		sizes["CSS"] += m[3] - m[2]
+		embedded += m[3] - m[2]
+	}
+	for language, size := range sizes {
+		if size == 0 {
+			delete(sizes, language)
+		}
+	}
+	if html := len(contents) - embedded; html > 0 {
+		sizes["HTML"] = html
+	}
+	return sizes
+}
+
+// the fences of front matter: YAML as used by Jekyll, or TOML by Hugo
+var frontMatterFences = []struct {
+	fence, language string
+}{
+	{"---", "YAML"},
+	{"+++", "TOML"},
+}
+
+// Finds the front matter at the start of contents, such as the YAML block
+// between "---" lines at the top of a Jekyll post, returning its language
+// and its size in bytes, including the fences.
+//
+// Returns the empty string and 0 if contents do not start with front matter,
+// or it is never closed.
+func FrontMatter(contents []byte) (language string, size int) {
+	line := func(start int) (text string, end int) {
+		end = len(contents)
+		if i := bytes.IndexByte(contents[start:], '\n'); i >= 0 {
+			end = start + i + 1
+		}
+		return strings.TrimRight(string(contents[start:end]), "\r\n"), end
+	}
+	first, end := line(0)
+	for _, f := range frontMatterFences {
+		if first != f.fence {
+			continue
+		}
+		for start := end; start < len(contents); start = end {
+			var text string
+			text, end = line(start)
+			if text == f.fence {
+				return f.language, end
+			}
+		}
+	}
+	return "", 0
+}
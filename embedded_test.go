@@ -0,0 +1,59 @@
+package linguist
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitHTML(t *testing.T) {
+	for _, tt := range []struct {
+		html string
+		want map[string]int
+	}{
+		{
+			// the bytes of the script and the style, and the HTML around them
+			`<html><head><script>var x = 1;</script><style>p { x: y}</style></head><body></body></html>` + "\n",
+			map[string]int{"JavaScript": 10, "CSS": 9, "HTML": 72},
+		},
+		{
+			`<script type="application/ld+json">{"a": 1}</script><script type="text/template"><p></p></script>`,
+			map[string]int{"JSON": 8, "HTML": 89},
+		},
+		{"<p>plain</p>\n", map[string]int{"HTML": 13}},
+		{"<script></script>", map[string]int{"HTML": 17}},
+	} {
+		if got := SplitHTML([]byte(tt.html)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SplitHTML(%q) = %v, want %v", tt.html, got, tt.want)
+		}
+		total := 0
+		for _, size := range tt.want {
+			total += size
+		}
+		if total != len(tt.html) {
+			t.Errorf("%q: sizes add up to %d, not %d", tt.html, total, len(tt.html))
+		}
+	}
+}
+
+func TestFrontMatter(t *testing.T) {
+	for _, tt := range []struct {
+		contents string
+		language string
+		size     int
+	}{
+		{"---\nlayout: post\ntitle: Hello\n---\n# Hello\n", "YAML", 34},
+		{"---\r\nlayout: post\r\n---\r\nText\r\n", "YAML", 24},
+		{"+++\ntitle = \"Hello\"\n+++\nText\n", "TOML", 24},
+		{"---\n---\n", "YAML", 8},
+		// not closed, or not at the start
+		{"---\nlayout: post\n# Hello\n", "", 0},
+		{"# Hello\n---\nlayout: post\n---\n", "", 0},
+		{"----\nx\n----\n", "", 0},
+		{"", "", 0},
+	} {
+		language, size := FrontMatter([]byte(tt.contents))
+		if language != tt.language || size != tt.size {
+			t.Errorf("FrontMatter(%q) = %q, %d, want %q, %d", tt.contents, language, size, tt.language, tt.size)
+		}
+	}
+}
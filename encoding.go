@@ -0,0 +1,73 @@
+package linguist
+
+import (
+	"bytes"
+	"unicode/utf8"
+)
+
+// Character encodings, see Encoding.
+const (
+	EncodingUTF8        = "UTF-8"
+	EncodingLatin1      = "ISO-8859-1"
+	EncodingWindows1252 = "Windows-1252"
+)
+
+// Reports the character encoding of contents, a best guess at one of the
+// Encoding* constants: UTF-8 if contents is valid UTF-8 (allowing for a
+// character cut off at the end, as by ReadHead), otherwise one of the single
+// byte encodings common in legacy source files.
+func Encoding(contents []byte) string {
+	if isUTF8(contents) {
+		return EncodingUTF8
+	}
+	for _, c := range contents {
+		if c >= 0x80 && c < 0xa0 && windows1252[c-0x80] != rune(c) {
+			return EncodingWindows1252
+		}
+	}
+	return EncodingLatin1
+}
+
+// isUTF8 is utf8.Valid, ignoring an incomplete character at the end
+func isUTF8(contents []byte) bool {
+	for i := 1; i < utf8.UTFMax && i <= len(contents); i++ {
+		if utf8.RuneStart(contents[len(contents)-i]) {
+			if !utf8.FullRune(contents[len(contents)-i:]) {
+				contents = contents[:len(contents)-i]
+			}
+			break
+		}
+	}
+	return utf8.Valid(contents)
+}
+
+// toUTF8 returns contents transcoded to UTF-8, unless it is UTF-8 already,
+// so that content based rules work the same for single byte encodings.
+//
+// Windows-1252 is a superset of the printable ISO-8859-1 characters, so is
+// used for both.
+func toUTF8(contents []byte) []byte {
+	if isUTF8(contents) {
+		return contents
+	}
+	var buf bytes.Buffer
+	buf.Grow(len(contents) + len(contents)/8)
+	for _, c := range contents {
+		switch {
+		case c < 0x80:
+			buf.WriteByte(c)
+		case c < 0xa0:
+			buf.WriteRune(windows1252[c-0x80])
+		default:
+			buf.WriteRune(rune(c))
+		}
+	}
+	return buf.Bytes()
+}
+
+// the characters for 0x80 to 0x9f in Windows-1252, the five undefined ones
+// left as the ISO-8859-1 control characters
+var windows1252 = [32]rune{
+	'€', 0x81, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0x8d, 'Ž', 0x8f,
+	0x90, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0x9d, 'ž', 'Ÿ',
+}
@@ -0,0 +1,44 @@
+package linguist
+
+import "testing"
+
+func TestEncoding(t *testing.T) {
+	for contents, want := range map[string]string{
+		"plain ASCII\n":         EncodingUTF8,
+		"café\n":                EncodingUTF8,
+		"cut off \xc3":          EncodingUTF8,
+		"caf\xe9\n":             EncodingLatin1,
+		"\x93quoted\x94 \x80\n": EncodingWindows1252,
+		// undefined in Windows-1252
+		"\x81\x8d\n": EncodingLatin1,
+	} {
+		if got := Encoding([]byte(contents)); got != want {
+			t.Errorf("Encoding(%q) = %q, want %q", contents, got, want)
+		}
+	}
+}
+
+func TestToUTF8(t *testing.T) {
+	for contents, want := range map[string]string{
+		"café\n":                "café\n",
+		"caf\xe9\n":             "café\n",
+		"\x93quoted\x94 \x80\n": "“quoted” €\n",
+	} {
+		if got := string(toUTF8([]byte(contents))); got != want {
+			t.Errorf("toUTF8(%q) = %q, want %q", contents, got, want)
+		}
+	}
+}
+
+// A latin-1 source file is classified by its contents, rather than ignored
+// as binary, and its size is still the number of bytes on disk.
+func TestAnalyzeLatin1(t *testing.T) {
+	contents := []byte("# -*- coding: latin-1 -*-\n# r\xe9sum\xe9 des donn\xe9es\nimport sys\n\n\ndef main():\n    print('caf\xe9')\n\n\nif __name__ == '__main__':\n    main()\n")
+	fi := Analyze("resume", contents)
+	if fi.IsBinary || fi.Language != "Python" || fi.Size != len(contents) {
+		t.Errorf("Analyze = %+v, want %d bytes of Python", fi, len(contents))
+	}
+	testDetect(t, []detectCase{
+		{"util.h", "// fonctions utilis\xe9es partout\n#include <vector>\nstd::vector<int> v;\n", "C++"},
+	})
+}
@@ -0,0 +1,48 @@
+package linguist
+
+import "bytes"
+
+// Kinds of line endings, see LineEndings.
+const (
+	EOLUnix    = "LF"
+	EOLWindows = "CRLF"
+	EOLMac     = "CR" // classic Mac OS
+	EOLMixed   = "mixed"
+)
+
+// Reports which line endings are used in contents: one of the EOL* constants,
+// or the empty string if contents has no line breaks at all.
+func LineEndings(contents []byte) string {
+	var lf, crlf, cr int
+	for i, c := range contents {
+		switch {
+		case c == '\n' && i > 0 && contents[i-1] == '\r':
+			crlf++
+		case c == '\n':
+			lf++
+		case c == '\r' && (i+1 == len(contents) || contents[i+1] != '\n'):
+			cr++
+		}
+	}
+	switch {
+	case lf+crlf+cr == 0:
+		return ""
+	case lf == 0 && cr == 0:
+		return EOLWindows
+	case crlf == 0 && cr == 0:
+		return EOLUnix
+	case lf == 0 && crlf == 0:
+		return EOLMac
+	}
+	return EOLMixed
+}
+
+// normalizeEOL returns contents with "\r\n" and "\r" line endings replaced by
+// "\n", so that line based rules work the same for any of them.
+func normalizeEOL(contents []byte) []byte {
+	if bytes.IndexByte(contents, '\r') < 0 {
+		return contents
+	}
+	contents = bytes.Replace(contents, []byte("\r\n"), []byte("\n"), -1)
+	return bytes.Replace(contents, []byte("\r"), []byte("\n"), -1)
+}
@@ -0,0 +1,45 @@
+package linguist
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLineEndings(t *testing.T) {
+	for contents, want := range map[string]string{
+		"":                 "",
+		"no line break":    "",
+		"a\nb\n":           EOLUnix,
+		"a\r\nb\r\n":       EOLWindows,
+		"a\rb\r":           EOLMac,
+		"a\r\nb\n":         EOLMixed,
+		"a\rb\n":           EOLMixed,
+		"a\r\nb\rc\r\n":    EOLMixed,
+		"trailing CR\r":    EOLMac,
+		"one\r\n":          EOLWindows,
+		"a\nb\n\x00binary": EOLUnix,
+	} {
+		if got := LineEndings([]byte(contents)); got != want {
+			t.Errorf("LineEndings(%q) = %q, want %q", contents, got, want)
+		}
+	}
+}
+
+// Line based heuristics work the same whatever the line endings.
+func TestDetectLineEndings(t *testing.T) {
+	cases := []detectCase{
+		{"run", "#!/bin/bash\necho hi\n", "Shell"},
+		{"x.h", "#include <vector>\nstd::vector<int> v;\n", "C++"},
+		{"x.h", "@interface Foo\n@end\n", "Objective-C"},
+		{"boot", "bits 64\nsection .text\nglobal _start\n_start:\n    mov rax, 60\n    syscall\n", "Assembly"},
+	}
+	for _, eol := range []string{"\r\n", "\r"} {
+		converted := []detectCase{}
+		for _, tt := range cases {
+			converted = append(converted, detectCase{tt.filename, strings.Replace(tt.contents, "\n", eol, -1), tt.want})
+		}
+		testDetect(t, converted)
+	}
+	// "\n" on the last line only
+	testDetect(t, []detectCase{{"run", "#!/bin/bash\r\necho hi\r\necho bye\n", "Shell"}})
+}
@@ -37,9 +37,64 @@ func ShouldIgnoreContents(contents []byte) bool {
 	return IsBinary(contents)
 }
 
+// Reasons returned by IgnoreReason.
+const (
+	IgnoredVendored      = "vendored"
+	IgnoredGenerated     = "generated"
+	IgnoredDocumentation = "documentation"
+	IgnoredGitIgnore     = "gitignore"
+	IgnoredBinary        = "binary"
+)
+
+// Checks if a file should be excluded from language statistics, and why.
+//
+// reason is one of the Ignored* constants, or the empty string when the file
+// should not be ignored. contents may be nil, in which case only checks based
+// on path are performed. .gitignore files are not read, so IgnoredGitIgnore
+// is only returned by Detector.IgnoreReason and Scanner.
+//
+// (this simply calls FilenameIgnoreReason and ContentsIgnoreReason)
+func IgnoreReason(path string, contents []byte) (ignored bool, reason string) {
+	reason = FilenameIgnoreReason(path)
+	if reason == "" && contents != nil {
+		reason = ContentsIgnoreReason(path, contents)
+	}
+	return reason != "", reason
+}
+
+// Checks if path should be excluded from language statistics based on its
+// name alone, returning the reason (see IgnoreReason) or the empty string.
+func FilenameIgnoreReason(path string) string {
+	switch {
+	case IsVendored(path):
+		return IgnoredVendored
+	case IsDocumentation(path):
+		return IgnoredDocumentation
+	}
+	return ""
+}
+
+// Checks if a file should be excluded from language statistics based on its
+// contents, returning the reason (see IgnoreReason) or the empty string.
+func ContentsIgnoreReason(path string, contents []byte) string {
+	switch {
+	case IsBinary(contents):
+		return IgnoredBinary
+	case IsGenerated(path, contents):
+		return IgnoredGenerated
+	}
+	return ""
+}
+
 var vendorRE *regexp.Regexp
 var doxRE *regexp.Regexp
 
+// GitHub Actions workflows, and the directories leading to them, which are
+// counted as YAML unlike everything else in .github. Only those at the root
+// of the repository run, so a .github directory of a vendored dependency is
+// vendored along with the rest of it.
+var workflowRE = regexp.MustCompile(`^\.github/(?:workflows/(?:[^/]+\.ya?ml)?)?$`)
+
 func init() {
 	var regexps []string
 	bytes := []byte(files["data/vendor.yml"])
@@ -59,8 +114,11 @@ func init() {
 }
 
 // Checks if path contains a filename commonly belonging to configuration files.
+//
+// Workflows in .github/workflows at the root of the repository are not,
+// although the rest of .github is.
 func IsVendored(path string) bool {
-	return vendorRE.MatchString(path)
+	return vendorRE.MatchString(path) && !workflowRE.MatchString(path)
 }
 
 // Checks if path contains a filename commonly belonging to documentation.
@@ -68,6 +126,20 @@ func IsDocumentation(path string) bool {
 	return doxRE.MatchString(path)
 }
 
+// Test files by the naming conventions of common test frameworks, e.g.
+// foo_test.go, test_foo.py, foo.spec.ts and FooTest.java, and anything in a
+// test, tests, spec or __tests__ directory
+var testRE = regexp.MustCompile(`(?:^|/)(?:tests?|spec|__tests__)/|` +
+	`(?:^|/)(?:[^/]+_test\.(?:go|py|rb|exs|cpp|cc|c)|test_[^/]+\.py|[^/]+_spec\.rb|` +
+	`[^/]+\.(?:test|spec)\.[cm]?[jt]sx?|[^/]+(?:Tests?|Spec)\.(?:java|kt|scala|groovy|cs|swift|php))$`)
+
+// Checks if path looks like a test file rather than production code, see
+// testRE. Unlike vendored and documentation files, test files are counted
+// unless a caller chooses to leave them out.
+func IsTest(path string) bool {
+	return testRE.MatchString(path)
+}
+
 // Checks contents for known character escape codes which
 // frequently show up in binary files but rarely (if ever) in text.
 //
@@ -0,0 +1,100 @@
+package linguist
+
+import "testing"
+
+func TestIsVendoredWorkflows(t *testing.T) {
+	for path, want := range map[string]bool{
+		".github/":                                  false,
+		".github/workflows/":                        false,
+		".github/workflows/ci.yml":                  false,
+		".github/workflows/release.yaml":            false,
+		".github/CODEOWNERS":                        true,
+		".github/workflows/scripts/build.sh":        true,
+		"node_modules/dep/.github/workflows/ci.yml": true,
+		"vendor/lib/.github/workflows/ci.yml":       true,
+		"sub/.github/workflows/ci.yml":              true,
+	} {
+		if got := IsVendored(path); got != want {
+			t.Errorf("IsVendored(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestIsVendoredDart(t *testing.T) {
+	for path, want := range map[string]bool{
+		".dart_tool/package_config.json":                true,
+		".dart_tool/build/generated/app/main.dart":      true,
+		"packages/app/.dart_tool/flutter_build/x.dart":  true,
+		".pub-cache/hosted/pub.dev/http-1.0.0/lib.dart": true,
+		"lib/main.dart":  false,
+		"pubspec.yaml":   false,
+		"lib/dart_tool/": false,
+	} {
+		if got := IsVendored(path); got != want {
+			t.Errorf("IsVendored(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestIsTest(t *testing.T) {
+	for path, want := range map[string]bool{
+		"pkg/scan_test.go":          true,
+		"test_scan.py":              true,
+		"lib/scan_test.py":          true,
+		"spec/scan_spec.rb":         true,
+		"app/scan_spec.rb":          true,
+		"src/scan.spec.ts":          true,
+		"src/scan.test.jsx":         true,
+		"src/ScanTest.java":         true,
+		"src/ScanTests.cs":          true,
+		"src/ScanSpec.scala":        true,
+		"tests/helpers.c":           true,
+		"src/__tests__/scan.js":     true,
+		"pkg/scan.go":               false,
+		"testing.py":                false,
+		"src/latest.ts":             false,
+		"src/Attestation.java":      false,
+		"contest/main.go":           false,
+		"testdata/fixture.json":     false,
+		"specification/overview.md": false,
+	} {
+		if got := IsTest(path); got != want {
+			t.Errorf("IsTest(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestIgnoreReason(t *testing.T) {
+	d := &Detector{IsGitIgnored: func(path string) bool { return path == "build/out.go" }}
+	for _, tt := range []struct {
+		path     string
+		contents string
+		want     string
+	}{
+		{"main.go", "package main\n", ""},
+		{"build/out.go", "package out\n", IgnoredGitIgnore},
+		{"vendor/github.com/x/y/y.go", "package y\n", IgnoredVendored},
+		{"node_modules/left-pad/index.js", "module.exports = 1;\n", IgnoredVendored},
+		{"docs/guide.md", "# Guide\n", IgnoredDocumentation},
+		{"api.pb.go", "// Code generated by protoc-gen-go. DO NOT EDIT.\npackage api\n", IgnoredGenerated},
+		{"logo.png", "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR", IgnoredBinary},
+	} {
+		ignored, reason := d.IgnoreReason(tt.path, []byte(tt.contents))
+		if reason != tt.want || ignored != (tt.want != "") {
+			t.Errorf("IgnoreReason(%q) = %v, %q, want %q", tt.path, ignored, reason, tt.want)
+		}
+	}
+
+	// the function reads no .gitignore files, and neither does a Detector
+	// without IsGitIgnored
+	for _, reason := range []string{FilenameIgnoreReason("build/out.go"), (&Detector{}).FilenameIgnoreReason("build/out.go")} {
+		if reason != "" {
+			t.Errorf("FilenameIgnoreReason(%q) = %q, want not ignored", "build/out.go", reason)
+		}
+	}
+
+	// without contents, only the name is considered
+	if ignored, reason := IgnoreReason("logo.png", nil); ignored {
+		t.Errorf("IgnoreReason(%q, nil) = %v, %q, want not ignored", "logo.png", ignored, reason)
+	}
+}
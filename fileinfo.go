@@ -0,0 +1,78 @@
+package linguist
+
+// FileInfo describes everything this package can tell about a file,
+// see Analyze.
+type FileInfo struct {
+	// The path the file was analyzed as.
+	Path string `json:"path"`
+
+	// The detected language, empty if unknown.
+	Language string `json:"language"`
+
+	// The type of Language, see LanguageType.
+	Type string `json:"type"`
+
+	// The number of bytes of contents analyzed.
+	Size int `json:"size"`
+
+	// See IsVendored, IsGenerated, IsBinary and IsDocumentation.
+	IsVendored      bool `json:"is_vendored"`
+	IsGenerated     bool `json:"is_generated"`
+	IsBinary        bool `json:"is_binary"`
+	IsDocumentation bool `json:"is_documentation"`
+
+	// How Language was determined, one of the Strategy* constants,
+	// or empty if it was not.
+	Strategy string `json:"strategy"`
+}
+
+// Ignored reports whether the file would be excluded from language
+// statistics, see IgnoreReason.
+func (fi FileInfo) Ignored() bool {
+	return fi.IsVendored || fi.IsGenerated || fi.IsBinary || fi.IsDocumentation
+}
+
+// Collects all signals about the file at path with the given contents in a
+// single call: its language, and whether it should be ignored.
+//
+// The language is detected even for files which should be ignored.
+func Analyze(path string, contents []byte) FileInfo {
+	return (&Detector{}).Analyze(path, contents)
+}
+
+// Like the Analyze function, using d to detect the language, and applying
+// any post processors (see AddPostProcessor) to the result.
+func (d *Detector) Analyze(path string, contents []byte) FileInfo {
+	return d.postProcess(d.analyze(path, contents))
+}
+
+// analyze is Analyze without post processors
+func (d *Detector) analyze(path string, contents []byte) FileInfo {
+	fi := FileInfo{
+		Path:            path,
+		Size:            len(contents),
+		IsVendored:      d.IsVendored(path),
+		IsGenerated:     IsGenerated(path, contents),
+		IsBinary:        IsBinary(contents),
+		IsDocumentation: IsDocumentation(path),
+	}
+	fi.Language, fi.Strategy = d.detect(path, contents)
+	fi.Type = LanguageType(fi.Language)
+	return fi
+}
+
+// postProcess applies the post processors to fi, see AddPostProcessor
+func (d *Detector) postProcess(fi FileInfo) FileInfo {
+	path := fi.Path
+	for _, fn := range d.postProcessors {
+		language, typ := fi.Language, fi.Type
+		fi = fn(fi)
+		if fi.Language != language {
+			d.logf("%s got result by post processor: %s", path, fi.Language)
+			if fi.Type == typ {
+				fi.Type = LanguageType(fi.Language)
+			}
+		}
+	}
+	return fi
+}
@@ -0,0 +1,38 @@
+package linguist
+
+import "testing"
+
+func TestAnalyze(t *testing.T) {
+	for _, tt := range []struct {
+		path     string
+		contents string
+		want     FileInfo
+	}{
+		{
+			"main.go", "package main\n",
+			FileInfo{Path: "main.go", Language: "Go", Type: "programming", Size: 13, Strategy: StrategyExtension},
+		},
+		{
+			"vendor/github.com/x/api/api.pb.go", "// Code generated by protoc-gen-go. DO NOT EDIT.\npackage api\n",
+			FileInfo{Path: "vendor/github.com/x/api/api.pb.go", Language: "Go", Type: "programming", Size: 61, IsVendored: true, IsGenerated: true, Strategy: StrategyExtension},
+		},
+		// .md is also GCC Machine Description
+		{
+			"docs/guide.md", "# Guide\n",
+			FileInfo{Path: "docs/guide.md", Language: "Markdown", Type: "prose", Size: 8, IsDocumentation: true, Strategy: StrategyHeuristics},
+		},
+	} {
+		got := Analyze(tt.path, []byte(tt.contents))
+		if got != tt.want {
+			t.Errorf("Analyze(%q) =\n%+v, want\n%+v", tt.path, got, tt.want)
+		}
+		if got.Ignored() != (tt.want.IsVendored || tt.want.IsGenerated || tt.want.IsBinary || tt.want.IsDocumentation) {
+			t.Errorf("Analyze(%q).Ignored() = %v", tt.path, got.Ignored())
+		}
+	}
+
+	// the language is still guessed for binary files, which are ignored
+	if fi := Analyze("logo.png", []byte("\x89PNG\r\n\x1a\n\x01\x02")); !fi.IsBinary || !fi.Ignored() {
+		t.Errorf("Analyze(%q) = %+v, want binary", "logo.png", fi)
+	}
+}
@@ -6,6 +6,10 @@
 
 // Command bake reads a set of files and writes a Go source file to "static.go"
 // that declares a map of string constants containing contents of the input files.
+//
+// The overlay of an input file, e.g. data/languages.overlay.yml for
+// data/languages.yml, is merged into it first, see mergeOverlay, so that
+// local changes survive updating the files copied from github/linguist.
 package main
 
 import (
@@ -14,6 +18,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"strings"
 	"unicode/utf8"
 )
 
@@ -39,6 +44,12 @@ func bake() error {
 		if err != nil {
 			return err
 		}
+		overlay, err := ioutil.ReadFile(strings.TrimSuffix(fn, ".yml") + ".overlay.yml")
+		if err == nil {
+			b = mergeOverlay(b, overlay)
+		} else if !os.IsNotExist(err) {
+			return err
+		}
 		fmt.Fprintf(w, "\t%q: ", fn)
 		if utf8.Valid(b) {
 			fmt.Fprintf(w, "`%s`", sanitize(b))
@@ -54,6 +65,135 @@ func bake() error {
 	return f.Close()
 }
 
+// mergeOverlay returns the YAML file b with overlay merged into it.
+//
+// The comments at the top of overlay, up to the first blank line, are left
+// out. An overlay of a list, such as vendor.yml, is appended to it. An overlay
+// of a map of entries formatted like languages.yml adds entries which b does
+// not have, in order of their names, and changes those it has: its lists,
+// such as extensions, are extended with the items of the overlay, but an item
+// such as "!Makefile.am" is removed, and its other fields are replaced.
+func mergeOverlay(b, overlay []byte) []byte {
+	lines := strings.Split(string(overlay), "\n")
+	for len(lines) > 0 && strings.HasPrefix(lines[0], "#") {
+		lines = lines[1:]
+	}
+	head, entries := splitEntries(strings.Split(strings.TrimSuffix(string(b), "\n"), "\n"))
+	_, overlays := splitEntries(lines)
+	if len(overlays) == 0 {
+		return append(append(b, '\n'), strings.TrimLeft(strings.Join(lines, "\n"), "\n")...)
+	}
+overlays:
+	for _, o := range overlays {
+		for i, e := range entries {
+			if e[0] == o[0] {
+				entries[i] = mergeEntry(e, o)
+				continue overlays
+			}
+		}
+		i := 0
+		for i < len(entries) && strings.ToLower(entries[i][0]) < strings.ToLower(o[0]) {
+			i++
+		}
+		entries = append(entries[:i], append([][]string{o}, entries[i:]...)...)
+	}
+	merged := head
+	for _, e := range entries {
+		merged = append(merged, e...)
+	}
+	return []byte(strings.Join(merged, "\n") + "\n")
+}
+
+// splitEntries splits the lines of a YAML map, one "name:" line followed by
+// its indented fields for each entry, into the lines before the first entry
+// and the lines of every entry
+func splitEntries(lines []string) (head []string, entries [][]string) {
+	for _, line := range lines {
+		switch {
+		case line != "" && !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "#") && !strings.HasPrefix(line, "-") && strings.HasSuffix(line, ":"):
+			entries = append(entries, []string{line})
+		case len(entries) == 0:
+			head = append(head, line)
+		default:
+			entries[len(entries)-1] = append(entries[len(entries)-1], line)
+		}
+	}
+	return head, entries
+}
+
+// mergeEntry merges the fields of the overlay entry o into the entry e, see
+// mergeOverlay
+func mergeEntry(e, o []string) []string {
+	for i := 1; i < len(o); i++ {
+		field := o[i]
+		if !strings.HasPrefix(field, "  ") || strings.HasPrefix(field, "  -") {
+			continue
+		}
+		items := []string{}
+		for i+1 < len(o) && strings.HasPrefix(o[i+1], "  - ") {
+			i++
+			items = append(items, o[i])
+		}
+
+		key := field[:strings.Index(field, ":")+1]
+		at := fieldIndex(e, key)
+		if at < 0 {
+			// a new field, before language_id which comes last
+			if at = fieldIndex(e, "  language_id:"); at < 0 {
+				at = len(e)
+			}
+			added := []string{field}
+			for _, item := range items {
+				if !strings.HasPrefix(itemValue(item), "!") {
+					added = append(added, item)
+				}
+			}
+			e = append(e[:at], append(added, e[at:]...)...)
+			continue
+		}
+		if len(items) == 0 {
+			e[at] = field
+			continue
+		}
+		end := at + 1
+		for end < len(e) && strings.HasPrefix(e[end], "  - ") {
+			end++
+		}
+		list := append([]string{}, e[at+1:end]...)
+		for _, item := range items {
+			if value := itemValue(item); strings.HasPrefix(value, "!") {
+				kept := list[:0]
+				for _, existing := range list {
+					if itemValue(existing) != value[1:] {
+						kept = append(kept, existing)
+					}
+				}
+				list = kept
+			} else {
+				list = append(list, item)
+			}
+		}
+		e = append(e[:at+1], append(list, e[end:]...)...)
+	}
+	return e
+}
+
+// fieldIndex returns the index of the line of the field with key, such as
+// "  extensions:", in the entry e, or -1 if there is none
+func fieldIndex(e []string, key string) int {
+	for i, line := range e {
+		if line == key || strings.HasPrefix(line, key+" ") {
+			return i
+		}
+	}
+	return -1
+}
+
+// itemValue returns the value of the list item "  - value", unquoted
+func itemValue(item string) string {
+	return strings.Trim(strings.TrimPrefix(item, "  - "), `"`)
+}
+
 // sanitize prepares a valid UTF-8 string as a raw string constant.
 func sanitize(b []byte) []byte {
 	// Replace ` with `+"`"+`
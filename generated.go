@@ -0,0 +1,27 @@
+package linguist
+
+import "regexp"
+
+var (
+	// filenames produced by common code generators and minifiers
+	generatedFilenameRE = regexp.MustCompile(`(?:\.|-)min\.(?:js|css)$|\.(?:js|css)\.map$|\.pb\.(?:go|cc|h)$|_pb2(?:_grpc)?\.py$|\.pb\.gw\.go$|\.designer\.(?:cs|vb)$|\.g\.dart$|\.freezed\.dart$`)
+
+	// lockfiles written by package managers, which are usually huge
+	lockfileRE = regexp.MustCompile(`(?:^|/)(?:package-lock\.json|npm-shrinkwrap\.json|yarn\.lock|pnpm-lock\.yaml|bun\.lock|Cargo\.lock|poetry\.lock|Pipfile\.lock|uv\.lock|composer\.lock|Gemfile\.lock|Podfile\.lock|pubspec\.lock|mix\.lock|go\.sum|flake\.lock|\.terraform\.lock\.hcl)$`)
+
+	// markers generated files are conventionally headed with, including the
+	// output of the GNU build system, e.g. Makefile.in, aclocal.m4 and configure
+	generatedContentsRE = regexp.MustCompile(`Code generated .* DO NOT EDIT|@generated\b|Generated by the protocol buffer compiler|This file (?:is|was) (?:automatically |auto-)?generated|generated by automake|generated automatically by aclocal|Generated by GNU Autoconf`)
+)
+
+// Checks if path (e.g. "foo.pb.go" or "Cargo.lock") or a marker near the start
+// of contents indicates that the file was generated; contents may be nil.
+func IsGenerated(path string, contents []byte) bool {
+	if generatedFilenameRE.MatchString(path) || lockfileRE.MatchString(path) {
+		return true
+	}
+	if len(contents) > 512 {
+		contents = contents[:512]
+	}
+	return generatedContentsRE.Match(contents)
+}
@@ -0,0 +1,39 @@
+package linguist
+
+import "testing"
+
+func TestIsGenerated(t *testing.T) {
+	for _, tt := range []struct {
+		path     string
+		contents string
+		want     bool
+	}{
+		{"foo.go", "// Code generated by protoc-gen-go. DO NOT EDIT.\n\npackage foo\n", true},
+		{"foo.js", "/* @generated */\nmodule.exports = {};\n", true},
+		{"foo.py", "# This file was automatically generated by SWIG\n", true},
+		{"jquery.min.js", "", true},
+		{"foo.pb.go", "", true},
+		{"package-lock.json", "{}\n", true},
+		{"web/yarn.lock", "# yarn lockfile v1\n", true},
+		{"Cargo.lock", "version = 3\n", true},
+		{"poetry.lock", "[[package]]\n", true},
+		{"go.sum", "golang.org/x/text v0.3.0 h1:abc=\n", true},
+		{".terraform.lock.hcl", "provider \"aws\" {}\n", true},
+		// only the names package managers use
+		{"my.lock", "x\n", false},
+		{"go.summary", "x\n", false},
+		// the output of the GNU build system
+		{"Makefile.in", "# Makefile.in generated by automake 1.16.5 from Makefile.am.\n", true},
+		{"aclocal.m4", "# generated automatically by aclocal 1.16.5 -*- Autoconf -*-\n", true},
+		{"configure", "#! /bin/sh\n# Guess values for system-dependent variables.\n# Generated by GNU Autoconf 2.71.\n", true},
+		{"Makefile.am", "bin_PROGRAMS = hello\n", false},
+		{"foo.go", "package foo\n", false},
+		// hand written files may ask not to be edited too
+		{"LICENSE.txt", "Copyright (c) 2024. DO NOT EDIT this notice.\n", false},
+		{"config.yml", "# DO NOT EDIT: managed by the platform team\nkey: value\n", false},
+	} {
+		if got := IsGenerated(tt.path, []byte(tt.contents)); got != tt.want {
+			t.Errorf("IsGenerated(%q, %q) = %v, want %v", tt.path, tt.contents, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package linguist
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// a pattern from a gitignore(5) style file
+type ignoreRule struct {
+	re      *regexp.Regexp
+	except  bool
+	dirOnly bool
+}
+
+// The patterns of the ignore files read by a Scanner so far, see
+// Options.IgnoreFiles.
+type ignoreRules []ignoreRule
+
+// load adds the patterns from the file name in dir, if it exists, where dir
+// is relative to root and separated by slashes, "." for root itself. As in
+// git, patterns are relative to dir, and only match paths below it.
+func (rules *ignoreRules) load(d *Detector, root, dir, name string) error {
+	filename := filepath.Join(root, filepath.FromSlash(path.Join(dir, name)))
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	d.logf("reading ignore patterns from %s", filename)
+	base := dir
+	if base == "." {
+		base = ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+		rule := ignoreRule{}
+		if line[0] == '!' {
+			rule.except = true
+			line = line[1:]
+		}
+		rule.dirOnly = strings.HasSuffix(line, "/")
+		rule.re, err = compileGlob(line, base)
+		if err != nil {
+			d.logf("%s: bad pattern %s %v", filename, line, err)
+			continue
+		}
+		*rules = append(*rules, rule)
+	}
+	return nil
+}
+
+// match reports whether path is matched by rules, where as in git the last
+// matching pattern decides, and a "!" pattern includes it again
+func (rules ignoreRules) match(path string, isDir bool) bool {
+	ignored := false
+	for _, rule := range rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if rule.re.MatchString(path) {
+			ignored = !rule.except
+		}
+	}
+	return ignored
+}
@@ -0,0 +1,45 @@
+package linguist
+
+import "testing"
+
+func TestIgnoreRules(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		".gitignore":     "/top.log\n*.tmp\n",
+		"sub/.gitignore": "/build\ndocs/out\n*.bak\n!keep.tmp\n",
+	})
+	var rules ignoreRules
+	for _, dir := range []string{".", "sub", "missing"} {
+		if err := rules.load(&Detector{}, root, dir, ".gitignore"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, tt := range []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		// anchored to the root
+		{"top.log", false, true},
+		{"sub/top.log", false, false},
+		// anchored to sub/ by a leading or an embedded slash
+		{"sub/build", true, true},
+		{"build", true, false},
+		{"sub/x/build", true, false},
+		{"sub/docs/out", false, true},
+		{"docs/out", false, false},
+		{"sub/x/docs/out", false, false},
+		// not anchored, but still below sub/ only
+		{"sub/a.bak", false, true},
+		{"sub/deep/a.bak", false, true},
+		{"a.bak", false, false},
+		{"a.tmp", false, true},
+		{"sub/x.tmp", false, true},
+		{"sub/keep.tmp", false, false},
+		{"keep.tmp", false, true},
+	} {
+		if got := rules.match(tt.path, tt.isDir); got != tt.want {
+			t.Errorf("match(%q, %v) = %v, want %v", tt.path, tt.isDir, got, tt.want)
+		}
+	}
+}
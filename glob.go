@@ -0,0 +1,63 @@
+package linguist
+
+import (
+	"regexp"
+	"strings"
+)
+
+// compileGlob translates a gitignore(5) style pattern into a regular
+// expression matching slash separated paths relative to the repository root,
+// for gitignore(5) and gitattributes(5) files.
+//
+// base is the directory the pattern was read from, "" for the repository
+// root. Patterns containing a slash (other than a trailing one) are anchored
+// to base, otherwise they match a name at any depth below it.
+func compileGlob(pattern, base string) (*regexp.Regexp, error) {
+	pattern = strings.TrimSuffix(pattern, "/")
+	anchored := strings.Contains(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+
+	var re strings.Builder
+	re.WriteString("^")
+	if base != "" {
+		re.WriteString(regexp.QuoteMeta(base + "/"))
+	}
+	if !anchored {
+		re.WriteString("(?:.*/)?")
+	}
+
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/") && (i == 0 || pattern[i-1] == '/'):
+			re.WriteString("(?:.*/)?")
+			i += 2
+		case pattern[i:] == "**" && (i == 0 || pattern[i-1] == '/'):
+			re.WriteString(".*")
+			i++
+		case c == '*':
+			re.WriteString("[^/]*")
+		case c == '?':
+			re.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				re.WriteString(`\[`)
+				continue
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			re.WriteString("[" + strings.Replace(class, `\`, `\\`, -1) + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(pattern):
+			i++
+			re.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		default:
+			re.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	re.WriteString("$")
+	return regexp.Compile(re.String())
+}
@@ -0,0 +1,209 @@
+package linguist
+
+import "regexp"
+
+// A heuristic picks language for contents matching pattern.
+//
+// Heuristics are tried in order by LanguageByContents, before falling back to
+// the classifier, and similarly to heuristics.yml from
+// https://github.com/github/linguist are only used to choose between the
+// languages given as hints (or for any file, if there are no hints).
+//
+// A heuristic with hints of its own is only used when all of them are among
+// the hints given, i.e. only for files with a particular extension, and may
+// then pick a language beyond those hints, e.g. MDX for .md files.
+type heuristic struct {
+	language string
+	pattern  *regexp.Regexp
+	hints    []string
+}
+
+func rule(language, pattern string, hints ...string) heuristic {
+	return heuristic{language, regexp.MustCompile(pattern), hints}
+}
+
+var heuristics = []heuristic{
+	// shell dialects, for scripts without a shebang
+	rule("fish", `(?m)^\s*set\s+-[a-zA-Z]*[glUx][a-zA-Z]*\s+\w+|\$argv\b|\bstatus\s+(?:--)?is-\w+`),
+	rule("Shell", `(?m)^\s*(?:fi|esac|done)\s*(?:;.*)?$|^\s*\[\[\s|^\s*(?:declare|typeset)\s+-\w+\s`),
+
+	// Windows scripts without an extension: PowerShell by #Requires (its
+	// nearest thing to a shebang), param blocks and common cmdlets, and
+	// batch files by their commands and variables
+	rule("PowerShell", `(?im)^#Requires\s+-(?:Version|Modules|RunAsAdministrator|PSEdition)\b|^\s*param\s*\(\s*[\[$]|\b(?:Write-(?:Host|Output|Error|Verbose)|Get-ChildItem|Set-StrictMode|Import-Module)\s|\$ErrorActionPreference\b`),
+	rule("Batchfile", `(?im)^\s*@?echo\s+off\b|%~d?p?[0-9]|^\s*setlocal\b|^\s*goto\s+:?\w+\s*$|^\s*if\s+(?:not\s+)?errorlevel\s`),
+
+	// assembly dialects, as .asm, .s and .S are shared between several
+	rule("Motorola 68K Assembly", `(?im)^\s*move[aqm]?\.[bwl]\s+\S+,\s*[ad][0-7]\b`),
+	// NASM by its sections, bits directive, or a label followed by moves or
+	// system calls, and by global and extern directives only where the name
+	// suggests assembly, as other languages declare the same
+	rule("Assembly", `(?im)^\s*(?:section|segment)\s+\.(?:text|data|bss|rodata)\b|^\s*bits\s+(?:16|32|64)\b|(?s:^[ \t]*[a-z_.][\w.]*:[ \t]*$.*?^[ \t]*(?:mov|syscall|int[ \t]+0x80)\b)`),
+	rule("Assembly", `(?im)^\s*(?:global|extern)\s+\w+`, "Assembly"),
+	rule("Unix Assembly", `(?m)^\s*\.(?:globl|global|section|text|data|type|size|p2align|intel_syntax|att_syntax)\b`),
+
+	// interface definition languages
+	rule("Protocol Buffer", `(?m)^\s*(?:syntax\s*=\s*"proto[23]"|edition\s*=\s*"\d+")\s*;`),
+	rule("Cap'n Proto", `(?m)^\s*@0x[0-9a-fA-F]{16}\s*;`),
+
+	// HCL blocks such as Terraform's, for files without an extension
+	rule("HCL", `(?m)^(?:(?:resource|data)\s+"[\w-]+"\s+"[\w-]+"|(?:variable|output|module|provider)\s+"[\w-]+"|terraform)\s*\{`),
+
+	// Nix expressions taking an attribute set, e.g. "{ pkgs, ... }:" or
+	// "{ pkgs ? import <nixpkgs> {} }:", for files without an extension
+	rule("Nix", `\A(?:\s*#.*\n)*\s*\{(?:[\w\s,?.\-"'@()<>]|\{[^{}]*\})*\}\s*(?:@\s*\w+\s*)?:\s|(?m)^\s*with\s+import\s+<nixpkgs>`),
+
+	// GraphQL schemas and operations, for files without an extension
+	rule("GraphQL", `(?m)^(?:schema|type\s+(?:Query|Mutation|Subscription)|(?:query|mutation|subscription)\s+\w+(?:\([^)]*\))?)\s*\{`),
+
+	// {{ }} template languages, for files without an extension: Handlebars
+	// by its block helpers, Liquid by its tags and filters with arguments,
+	// and Mustache by its plain sections
+	rule("Handlebars", `(?s)\{\{~?#(?:if|each|with|unless)\s.*?\{\{~?/(?:if|each|with|unless)~?\}\}`),
+	rule("Liquid", `\{%-?\s*(?:assign|capture|unless|increment|decrement|cycle|endunless|endcapture)\b|\{\{-?[^}]*\|\s*\w+:\s*[^}]*\}\}`),
+	rule("Mustache", `(?s)\{\{[#^]\s*[\w.]+\s*\}\}.*?\{\{/\s*[\w.]+\s*\}\}`),
+
+	// Solidity contracts, for files without an extension and to tell .sol
+	// files from Gerber images
+	rule("Solidity", `(?m)^\s*pragma\s+solidity\b|^\s*(?:abstract\s+)?contract\s+\w+(?:\s+is\s+[\w\s,.]+)?\s*\{`),
+
+	// WebAssembly text format, for files without an extension; the binary
+	// format (.wasm) is recognised as binary by its header
+	rule("WebAssembly", `\A(?:\s*;;.*\n)*\s*\(module(?:\s+\$[\w.]+)?\s*(?:\(|;;|$)`),
+
+	// Dart, for files without an extension, by its library imports or an
+	// asynchronous main, as a plain "void main() {" is just as much C or Java
+	rule("Dart", `(?m)^\s*import\s+['"](?:dart|package):[^'"]+['"]\s*;|^\s*Future<void>\s+main\s*\(\s*(?:List<String>\s+\w+)?\s*\)\s*async\b`),
+
+	// Julia, for files without an extension, by its using statements, typed
+	// function arguments, mutable structs, abstract types or macros like @time
+	rule("Julia", `(?m)^using\s+[A-Z]\w*(?:\.\w+)*(?:\s*[,:]\s*[@\w.]+)*\s*$|^\s*function\s+[\w.]+!?\([^)]*::|^\s*(?:mutable\s+struct|abstract\s+type)\s+[A-Z]\w*|^\s*@(?:time|show|testset|inbounds|assert)\s`),
+
+	// Haskell and its relatives, for files without an extension: Elm by its
+	// module exports, PureScript by its Prelude and effects, and literate
+	// Haskell by its code blocks or bird tracks
+	rule("Elm", `(?m)^(?:port\s+)?module\s+[A-Z][\w.]*\s+exposing\s*\(`),
+	rule("PureScript", `(?m)^import\s+(?:Prelude|Effect(?:\.\w+)*)\b|::\s*Effect\s+Unit\b`),
+	rule("Literate Haskell", `(?m)^\\begin\{code\}|^>\s*(?:module\s+[A-Z][\w.]*|import\s+[A-Z][\w.]*|main\s*::)`),
+	rule("Haskell", `(?m)^module\s+[A-Z][\w.]*(?:\s*\([^)]*\))?\s+where\b|^import\s+qualified\s+[A-Z]|^main\s*::\s*IO\b`),
+
+	// C family languages sharing .h, .m and .mm, after heuristics.yml
+	rule("Objective-C", `(?m)^\s*(?:@(?:interface|class|protocol|property|end|synchronised|selector|implementation)\b|#import\s+.+\.h[">])`),
+	rule("C++", `(?m)^\s*#\s*include <(?:cstdint|string|vector|map|list|array|bitset|queue|stack|forward_list|unordered_map|unordered_set|(?:i|o|io)stream)>|^\s*template\s*<|^[ \t]*(?:try|constexpr)\b|^[ \t]*catch\s*\(|^[ \t]*(?:class|(?:using[ \t]+)?namespace)\s+\w+|^[ \t]*(?:private|public|protected):$|std::\w+`, "C", "C++"),
+	rule("C", `(?s).`, "C", "C++", "Objective-C"),
+	rule("MATLAB", `(?m)^\s*%|^\s*function\b`, "MATLAB", "Objective-C"),
+	rule("XML", `^\s*<`, "Objective-C++", "XML"),
+	rule("Objective-C++", `(?s).`, "Objective-C++", "XML"),
+
+	// .r, which is R unless it has a Rebol header
+	rule("Rebol", `(?i)\bREBOL\s*\[`, "R", "Rebol"),
+	rule("R", `(?s).`, "R", "Rebol"),
+
+	// Perl and Raku sharing .pl, .pm and .t, after heuristics.yml, along with
+	// the other languages using those
+	rule("Prolog", `(?m)^[^#]*:-`, "Perl", "Prolog"),
+	rule("X PixMap", `^\s*/\* XPM \*/`, "Perl", "X PixMap"),
+	rule("Raku", `(?m)^\s*(?:use\s+v6\b|(?:unit\s+)?module\s+[\w:]+\s*[;{]|(?:my\s+)?class\s+[\w:]+(?:\s+is\s+[\w:]+)*\s*\{)|\bmy\s+\\\w`, "Perl", "Raku"),
+	rule("Perl", `(?m)\buse\s+(?:strict|warnings|v?5(?:\.\d+)*)\s*;`, "Perl", "Raku"),
+	rule("Turing", `(?m)^\s*%[ \t]+|^\s*var\s+\w+(?:\s*:\s*\w+)?\s*:=\s*\w+`, "Perl", "Turing"),
+
+	// .f and .for, which are Fortran in fixed form (comments in column 1,
+	// continuations in column 6 and statements from column 7) unless written
+	// in free form, but may be Forth or Filebench WML. Declarations with ::,
+	// trailing & continuations and "end program" or "end module" only occur in
+	// free form, whose indentation may otherwise look like fixed form, e.g. an
+	// indented ! comment or an assignment to c in column 1.
+	rule("Forth", `(?m)^: `, "Forth", "Fortran"),
+	rule("Filebench WML", `\bflowop\b`, "Filebench WML", "Fortran"),
+	rule("Fortran Free Form", `(?im)::|&[ \t]*(?:!.*)?$|^[ \t]*end[ \t]*(?:program|module)\b`, "Fortran"),
+	rule("Fortran", `(?im)^(?:[c*](?:[ \t]*$|[ \t]+[^\s=(]|[^a-z\s=(])|     (?:[^\s\w!=]|[1-9]))`, "Fortran"),
+	rule("Fortran Free Form", `(?im)^[ \t]{0,4}[a-z]`, "Fortran"),
+	rule("Fortran", `(?im)^      (?:subroutine|program|function|end|data|common|call|do|if|implicit|integer|real|double|character|logical|dimension|parameter|write|read|format)\b`, "Fortran"),
+
+	// .v, shared by Coq proofs, Verilog modules (or SystemVerilog ones,
+	// using always_ff and the like) and V, which has functions and a bare
+	// "module name" instead
+	rule("Coq", `(?m)(?:^|\s)(?:Proof|Qed|Defined|Admitted)\.(?:$|\s)|^\s*(?:Require\s+(?:Import|Export)|Theorem|Lemma|Inductive|Fixpoint)\s`, "Coq", "V", "Verilog"),
+	rule("SystemVerilog", systemVerilogPattern, "Coq", "V", "Verilog"),
+	rule("Verilog", verilogPattern, "Coq", "V", "Verilog"),
+	rule("V", `(?m)^\s*(?:pub\s+)?fn\s+(?:\([^)]*\)\s*)?\w+\s*\(|^\s*module\s+\w+\s*$|^\s*import\s+[\w.]+\s*$`, "Coq", "V", "Verilog"),
+
+	// hardware description languages, for files without an extension
+	rule("VHDL", `(?im)^\s*(?:library\s+ieee\s*;|use\s+ieee\.|entity\s+\w+\s+is\b|architecture\s+\w+\s+of\s+\w+\s+is\b)`),
+	rule("SystemVerilog", systemVerilogPattern),
+	rule("Verilog", verilogPattern),
+
+	// .ex, shared by Elixir and Euphoria, after heuristics.yml
+	rule("Elixir", `(?m)^\s*@moduledoc\s|^\s*(?:cond|import|quote|unless)\s|^\s*def(?:exception|impl|macro|module|protocol)[(\s]`, "Elixir", "Euphoria"),
+	rule("Euphoria", `(?m)^\s*namespace\s|^\s*(?:public\s+)?include\s|^\s*(?:(?:public|export|global)\s+)?(?:atom|constant|enum|function|integer|object|procedure|sequence|type)\s`, "Elixir", "Euphoria"),
+
+	// .sc, shared by Scala scripts (e.g. for Ammonite or scala-cli) and
+	// SuperCollider, with its environment variables and synth definitions
+	rule("SuperCollider", `(?m)\^(?:this|super)\.|^\s*~\w+\s*=|\bSynthDef\s*\(`, "Scala", "SuperCollider"),
+	rule("Scala", `(?m)^\s*(?:import\s+(?:scala|java|javax|akka|cats|zio)\.|(?:case\s+)?(?:class|object|trait)\s+\w+|(?:val|var|def)\s+\w+|@main\b|//>\s*using\s)`, "Scala", "SuperCollider"),
+
+	// .md, which is MDX if it starts with JSX imports or exports (after any
+	// front matter), unless it is a GCC machine description
+	rule("MDX", `\A(?:---\n(?s:.*?)\n---\n)?\s*(?:import\s[^\n]*\bfrom\s+['"]|import\s+['"]|export\s+(?:const|default|function)\b)`, "Markdown"),
+	rule("GCC Machine Description", `(?m)^(?:;;|\(define_)`, "GCC Machine Description", "Markdown"),
+	rule("Markdown", `(?s).`, "GCC Machine Description", "Markdown"),
+
+	// SQL dialects sharing .sql, after heuristics.yml
+	rule("PLpgSQL", `(?im)^\\i\b|AS\s+\$\$|LANGUAGE\s+'?plpgsql'?|^\s*BEGIN(?:\s+WORK)?\s*;`, "SQL", "PLpgSQL", "TSQL"),
+	rule("SQLPL", `(?im)ALTER\s+MODULE|MODE\s+DB2SQL|\bSYS(?:CAT|PROC)\.|ASSOCIATE\s+RESULT\s+SET|\bEND!\s*$`, "SQL", "SQLPL"),
+	rule("PLSQL", `(?i)\$\$PLSQL_|XMLTYPE|systimestamp|\.nextval|CONNECT\s+BY|AUTHID\s+(?:DEFINER|CURRENT_USER)|constructor\W+function`, "SQL", "PLSQL"),
+	rule("TSQL", `(?im)^\s*GO\s*$|BEGIN\s+(?:TRY|CATCH)|OUTPUT\s+INSERTED|DECLARE\s+@|\[dbo\]|@@\w+`, "SQL", "TSQL"),
+	rule("SQL", `(?s).`, "SQL", "PLpgSQL", "TSQL"),
+}
+
+// Verilog modules, and the constructs SystemVerilog adds to them
+const (
+	verilogPattern       = "(?m)^[ \\t]*(?:module\\s+\\w+\\s*(?:#\\s*)?\\(|endmodule\\b|`(?:define|ifdef|ifndef|include|timescale)\\b|always\\s*@|initial\\s+(?:begin|@))"
+	systemVerilogPattern = `(?m)^[ \t]*(?:always_(?:ff|comb|latch)\b|(?:input|output|inout)\s+logic\b|logic\s*\[\d|endinterface\b|endpackage\b|import\s+\w+::\*)`
+)
+
+// Attempts to pick one of hints (or any language, given no hints) using
+// simple content based rules.
+//
+// Returns the empty string if no rule matched.
+func languageByHeuristics(contents []byte, hints []string) string {
+	return languageByHeuristicsAmong(contents, hints, nil)
+}
+
+// languageByHeuristicsAmong is languageByHeuristics, skipping rules picking
+// languages other than candidates, unless there are none.
+func languageByHeuristicsAmong(contents []byte, hints, candidates []string) string {
+	for _, h := range heuristics {
+		if len(hints) > 0 && len(h.hints) == 0 && !hinted(hints, h.language) {
+			continue
+		}
+		if !hintedAll(hints, h.hints) {
+			continue
+		}
+		if len(candidates) > 0 && !hinted(candidates, h.language) {
+			continue
+		}
+		if h.pattern.Match(contents) {
+			return h.language
+		}
+	}
+	return ""
+}
+
+func hinted(hints []string, language string) bool {
+	for _, hint := range hints {
+		if hint == language {
+			return true
+		}
+	}
+	return false
+}
+
+func hintedAll(hints []string, languages []string) bool {
+	for _, language := range languages {
+		if !hinted(hints, language) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,370 @@
+package linguist
+
+import "testing"
+
+// a file and the language it should be detected as, "" for undetected
+type detectCase struct {
+	filename string
+	contents string
+	want     string
+}
+
+func testDetect(t *testing.T, cases []detectCase) {
+	t.Helper()
+	d := &Detector{}
+	for _, tt := range cases {
+		if got := d.Detect(tt.filename, []byte(tt.contents)); got != tt.want {
+			t.Errorf("Detect(%q, %q) = %q, want %q", tt.filename, tt.contents, got, tt.want)
+		}
+	}
+}
+
+// testNotDetected checks that none of the files are detected as language,
+// where what they are detected as instead is up to the classifier
+func testNotDetected(t *testing.T, language string, files map[string]string) {
+	t.Helper()
+	d := &Detector{}
+	for filename, contents := range files {
+		if got := d.Detect(filename, []byte(contents)); got == language {
+			t.Errorf("Detect(%q, %q) = %q", filename, contents, got)
+		}
+	}
+}
+
+func TestAssemblyHeuristics(t *testing.T) {
+	testDetect(t, []detectCase{
+		{"boot", "bits 64\nsection .text\nglobal _start\n_start:\n    mov rax, 60\n    syscall\n", "Assembly"},
+		{"hello", "_start:\n    mov eax, 1\n    int 0x80\n", "Assembly"},
+		{"hello.asm", "global _start\nextern printf\n", "Assembly"},
+		{"hello.s", "    .globl main\n    .text\nmain:\n    ret\n", "Unix Assembly"},
+		// preprocessed GAS, by the lowercase extension
+		{"start.S", "#include <asm.h>\n    .globl _start\n    .text\n_start:\n    ret\n", "Unix Assembly"},
+		{"boot.asm", "section .text\nglobal _start\n", "Assembly"},
+		{"BOOT.ASM", "section .text\nglobal _start\n", "Assembly"},
+		{"vectors.s", "    move.l  #0, d0\n    rts\n", "Motorola 68K Assembly"},
+		// other languages have extern declarations too
+		{"foo", "#include <stdio.h>\n\nextern int foo;\n\nint main(void) {\n    printf(\"%d\\n\", foo);\n    return 0;\n}\n", "C"},
+	})
+	testNotDetected(t, "Assembly", map[string]string{
+		"counter": "import sys\n\ncounter = 0\n\ndef bump():\n    global counter\n    counter += 1\n",
+	})
+}
+
+func TestDartHeuristics(t *testing.T) {
+	testDetect(t, []detectCase{
+		{"tool", "import 'dart:io';\n\nvoid main() {\n  print('hi');\n}\n", "Dart"},
+		{"app", "import 'package:flutter/material.dart';\n\nvoid main() => runApp(App());\n", "Dart"},
+		{"server", "Future<void> main(List<String> args) async {\n  await serve(args);\n}\n", "Dart"},
+		{"main.dart", "void main() {}\n", "Dart"},
+	})
+	testNotDetected(t, "Dart", map[string]string{
+		"prog":  "#include <stdio.h>\n\nvoid main() {\n    printf(\"hi\\n\");\n}\n",
+		"Hello": "public class Hello {\n    public static void main() {\n        System.out.println(\"hi\");\n    }\n}\n",
+	})
+}
+
+func TestShellHeuristics(t *testing.T) {
+	testDetect(t, []detectCase{
+		{"run", "#!/bin/bash\necho hi\n", "Shell"},
+		{"prompt", "#!/usr/bin/env fish\necho hi\n", "fish"},
+		{"config.fish", "echo hi\n", "fish"},
+		{"prompt", "set -gx PATH $PATH ~/bin\nfunction greet\n    echo hello $argv\nend\n", "fish"},
+		// POSIX sh, by shebang or by its syntax alone
+		{"setup", "#!/bin/sh\nif [ -f x ]; then\n    echo y\nfi\n", "Shell"},
+		{"setup", "if [ -f x ]; then\n    echo y\nfi\n", "Shell"},
+		{"deploy", "set -euo pipefail\nfor f in *; do\n    echo $f\ndone\n", "Shell"},
+	})
+	testNotDetected(t, "fish", map[string]string{
+		"deploy": "set -euo pipefail\nfor f in *; do\n    echo $f\ndone\n",
+	})
+}
+
+func TestIDLs(t *testing.T) {
+	testDetect(t, []detectCase{
+		{"api.proto", "syntax = \"proto3\";\n\nmessage Foo {}\n", "Protocol Buffer"},
+		{"api.thrift", "struct Foo {\n  1: string bar\n}\n", "Thrift"},
+		{"api.capnp", "@0xdbb9ad1f14bf0b36;\n\nstruct Foo {}\n", "Cap'n Proto"},
+		{"monster.fbs", "table Monster {\n  name: string;\n}\n", "FlatBuffers"},
+		{"api.avdl", "protocol Api {\n  record Foo { string bar; }\n}\n", "Avro IDL"},
+		// by contents alone
+		{"api", "syntax = \"proto3\";\n\npackage api;\n\nmessage Foo {\n  string bar = 1;\n}\n", "Protocol Buffer"},
+		{"api", "edition = \"2023\";\n\nmessage Foo {}\n", "Protocol Buffer"},
+		{"schema", "@0xdbb9ad1f14bf0b36;\n\nstruct Foo {\n  bar @0 :Text;\n}\n", "Cap'n Proto"},
+	})
+	testNotDetected(t, "Protocol Buffer", map[string]string{
+		"notes": "The syntax = \"proto3\" line goes first.\n",
+	})
+}
+
+func TestSQLHeuristics(t *testing.T) {
+	testDetect(t, []detectCase{
+		{"schema.sql", "CREATE TABLE users (\n  id INTEGER PRIMARY KEY,\n  name TEXT\n);\n", "SQL"},
+		{"fn.sql", "CREATE FUNCTION add(a integer, b integer) RETURNS integer AS $$\nBEGIN\n  RETURN a + b;\nEND;\n$$ LANGUAGE plpgsql;\n", "PLpgSQL"},
+		{"proc.sql", "CREATE PROCEDURE p AS\nBEGIN TRY\n  SELECT 1\nEND TRY\nBEGIN CATCH\nEND CATCH\nGO\n", "TSQL"},
+		{"pkg.sql", "CREATE OR REPLACE PACKAGE BODY p AUTHID DEFINER AS\n  v NUMBER := seq.nextval;\nEND;\n", "PLSQL"},
+		{"db2.sql", "CREATE PROCEDURE p LANGUAGE SQL MODE DB2SQL\nBEGIN\nEND!\n", "SQLPL"},
+	})
+	testNotDetected(t, "PLpgSQL", map[string]string{
+		"query.sql": "SELECT name FROM users WHERE id = 1;\n",
+	})
+}
+
+func TestHCLHeuristics(t *testing.T) {
+	testDetect(t, []detectCase{
+		{"main.tf", "resource \"aws_instance\" \"web\" {\n  ami = \"ami-123\"\n}\n", "HCL"},
+		{"prod.tfvars", "region = \"us-east-1\"\n", "HCL"},
+		{"config.hcl", "listener \"tcp\" {\n  address = \"127.0.0.1:8200\"\n}\n", "HCL"},
+		{".terraformrc", "plugin_cache_dir = \"$HOME/.terraform.d/plugin-cache\"\n", "HCL"},
+		// by its blocks alone
+		{"infra", "provider \"aws\" {\n  region = \"us-east-1\"\n}\n\nresource \"aws_s3_bucket\" \"b\" {\n  bucket = \"b\"\n}\n", "HCL"},
+		{"versions", "terraform {\n  required_version = \">= 1.0\"\n}\n", "HCL"},
+		{"outputs", "variable \"name\" {\n  type = string\n}\n", "HCL"},
+	})
+	testNotDetected(t, "HCL", map[string]string{
+		"conf": "[server]\nport = 8080\n",
+		"prog": "#include <stdio.h>\n\nint main(void) {\n    return 0;\n}\n",
+	})
+}
+
+func TestGraphQLHeuristics(t *testing.T) {
+	testDetect(t, []detectCase{
+		{"schema.graphql", "type Query {\n  user(id: ID!): User\n}\n", "GraphQL"},
+		{"user.gql", "query User($id: ID!) {\n  user(id: $id) { name }\n}\n", "GraphQL"},
+		// by contents alone
+		{"schema", "schema {\n  query: Query\n}\n\ntype Query {\n  users: [User!]!\n}\n", "GraphQL"},
+		{"api", "type Mutation {\n  addUser(name: String!): User\n}\n", "GraphQL"},
+		{"op", "mutation AddUser($name: String!) {\n  addUser(name: $name) { id }\n}\n", "GraphQL"},
+	})
+	testNotDetected(t, "GraphQL", map[string]string{
+		"types": "type User struct {\n\tName string\n}\n",
+	})
+}
+
+func TestAppleHeuristics(t *testing.T) {
+	testDetect(t, []detectCase{
+		{"App.swift", "import UIKit\n\nclass AppDelegate: UIResponder {}\n", "Swift"},
+		{"View.mm", "#import \"View.h\"\n#include <vector>\n\n@implementation View\n@end\n", "Objective-C++"},
+		{"Info.mm", "<?xml version=\"1.0\"?>\n<map/>\n", "XML"},
+		{"View.h", "#import <UIKit/UIKit.h>\n\n@interface View : UIView\n@property int count;\n@end\n", "Objective-C"},
+		{"View.m", "#import \"View.h\"\n\n@implementation View\n@end\n", "Objective-C"},
+		{"plot.m", "% plot a sine\nx = linspace(0, 1);\nplot(x, sin(x));\n", "MATLAB"},
+		{"util.h", "#include <vector>\nnamespace util { std::vector<int> v; }\n", "C++"},
+		{"util.h", "int add(int a, int b);\n", "C"},
+	})
+}
+
+func TestRHeuristics(t *testing.T) {
+	testDetect(t, []detectCase{
+		{"analysis.r", "library(ggplot2)\n\ndf <- read.csv(\"data.csv\")\nsummary(df)\n", "R"},
+		{"plot.R", "x <- c(1, 2, 3)\nplot(x)\n", "R"},
+		{"hello.r", "REBOL [\n    Title: \"Hello\"\n]\n\nprint \"Hello\"\n", "Rebol"},
+		{"old.r", "Rebol[Title: \"Old\"]\nprint 1\n", "Rebol"},
+		{"hello.reb", "REBOL []\nprint 1\n", "Rebol"},
+	})
+}
+
+func TestConfigLanguages(t *testing.T) {
+	testDetect(t, []detectCase{
+		{"default.nix", "{ pkgs ? import <nixpkgs> {} }:\npkgs.hello\n", "Nix"},
+		{"config.dhall", "let name = \"x\" in { name = name }\n", "Dhall"},
+		{"main.jsonnet", "local x = 1;\n{ x: x }\n", "Jsonnet"},
+		{"lib.libsonnet", "{ f(x):: x }\n", "Jsonnet"},
+		// Nix by contents alone
+		{"shell", "{ pkgs, lib, ... }:\n\npkgs.mkShell { buildInputs = [ pkgs.go ]; }\n", "Nix"},
+		{"env", "# development shell\n{ pkgs ? import <nixpkgs> {} }@args: pkgs.hello\n", "Nix"},
+		{"release", "{ pkgs ? import <nixpkgs> {}, system ? builtins.currentSystem }:\npkgs.hello\n", "Nix"},
+		{"overlay", "with import <nixpkgs> {};\nstdenv.mkDerivation { name = \"x\"; }\n", "Nix"},
+	})
+	testNotDetected(t, "Nix", map[string]string{
+		"data": "{\"key\": \"value\"}\n",
+	})
+}
+
+func TestWebAssemblyHeuristics(t *testing.T) {
+	testDetect(t, []detectCase{
+		{"add.wat", "(module\n  (func $add (param i32 i32) (result i32)\n    local.get 0\n    local.get 1\n    i32.add))\n", "WebAssembly"},
+		{"spec.wast", "(module (func (export \"f\")))\n(assert_return (invoke \"f\"))\n", "WebAssembly"},
+		// by contents alone
+		{"add", ";; adds two numbers\n(module $math\n  (func $add (param i32 i32) (result i32)))\n", "WebAssembly"},
+	})
+	testNotDetected(t, "WebAssembly", map[string]string{
+		"init": "(define (module x) x)\n",
+	})
+
+	wasm := []byte("\x00asm\x01\x00\x00\x00\x01\x07\x01\x60\x02\x7f\x7f\x01\x7f\x03\x02\x01\x00")
+	if fi := Analyze("add.wasm", wasm); !fi.IsBinary || !fi.Ignored() {
+		t.Errorf("Analyze(add.wasm) = %+v, want binary", fi)
+	}
+}
+
+func TestPerlHeuristics(t *testing.T) {
+	testDetect(t, []detectCase{
+		{"hello.pl", "#!/usr/bin/perl\nuse strict;\nuse warnings;\n\nmy @names = qw(a b);\nprint \"$_\\n\" for @names;\n", "Perl"},
+		{"Util.pm", "package Util;\nuse strict;\n\nsub add { return $_[0] + $_[1] }\n\n1;\n", "Perl"},
+		{"basic.t", "use strict;\nuse Test::More tests => 1;\nok(1);\n", "Perl"},
+		{"hello.raku", "say 'hello';\n", "Raku"},
+		{"Util.rakumod", "unit module Util;\n", "Raku"},
+		{"hello.pl", "use v6;\n\nmy @names = <a b>;\n.say for @names;\n", "Raku"},
+		{"Point.pm", "class Point is Object {\n    has $.x;\n}\n", "Raku"},
+		{"sigilless.pl", "my \\answer = 42;\nsay answer;\n", "Raku"},
+		{"family.pl", "parent(tom, bob).\ngrandparent(X, Z) :- parent(X, Y), parent(Y, Z).\n", "Prolog"},
+	})
+}
+
+func TestMarkdownHeuristics(t *testing.T) {
+	testDetect(t, []detectCase{
+		{"page.mdx", "# Hi\n", "MDX"},
+		{"report.Rmd", "# Intro\n\n```{r}\nx <- 1\n```\n", "RMarkdown"},
+		{"report.rmd", "# Intro\n", "RMarkdown"},
+		// MDX in .md files, by its imports and exports
+		{"sales.md", "import { Chart } from '../components/chart'\n\n# Sales\n\n<Chart />\n", "MDX"},
+		{"post.md", "---\ntitle: Post\n---\nexport const meta = {}\n\n# Post\n", "MDX"},
+		{"README.md", "# Title\n\nimport the module from npm:\n", "Markdown"},
+		{"i386.md", "(define_insn \"addsi3\"\n  [(set (match_operand:SI 0 \"register_operand\"))])\n", "GCC Machine Description"},
+	})
+}
+
+func TestTemplateHeuristics(t *testing.T) {
+	testDetect(t, []detectCase{
+		{"post.hbs", "<h1>{{title}}</h1>\n", "Handlebars"},
+		{"post.handlebars", "<h1>{{title}}</h1>\n", "Handlebars"},
+		{"user.mustache", "Hello {{name}}\n", "Mustache"},
+		{"page.liquid", "<h1>{{ page.title }}</h1>\n", "Liquid"},
+		// by contents alone
+		{"list", "<ul>\n{{#each items}}\n  <li>{{this}}</li>\n{{/each}}\n</ul>\n", "Handlebars"},
+		{"header", "{% assign title = page.title | upcase %}\n<h1>{{ title }}</h1>\n", "Liquid"},
+		{"date", "<time>{{ page.date | date: \"%Y-%m-%d\" }}</time>\n", "Liquid"},
+		{"people", "{{#people}}\n  <b>{{name}}</b>\n{{/people}}\n", "Mustache"},
+	})
+}
+
+func TestFortranHeuristics(t *testing.T) {
+	testDetect(t, []detectCase{
+		{"hello.f", "C     HELLO WORLD\n      PROGRAM HELLO\n      PRINT *, 'HELLO'\n      END\n", "Fortran"},
+		{"sum.f", "      SUBROUTINE SUM(A, B,\n     1               C)\n      C = A + B\n      END\n", "Fortran"},
+		{"HELLO.FOR", "* COMMENT\n      PRINT *, 'X'\n      END\n", "Fortran"},
+		{"hello.f90", "program hello\n  implicit none\n  print *, 'hello'\nend program hello\n", "Fortran Free Form"},
+		// free form in a .f file
+		{"mod.f", "module m\n  integer :: x = 1\nend module m\n", "Fortran Free Form"},
+		{"indented.f", "program p\n     ! indented comment\n  x = 1\nend program p\n", "Fortran Free Form"},
+		{"assign.f", "     ! set c\nc = 1\nc(2) = 3\nprint *, c\n", "Fortran Free Form"},
+		{"cont.f", "      X = 1\nC COMMENT\n     &  + 2\n      END SUBROUTINE\n", "Fortran"},
+		{"words.f", ": square dup * ;\n", "Forth"},
+		{"hello.cob", "       IDENTIFICATION DIVISION.\n       PROGRAM-ID. HELLO.\n", "COBOL"},
+		{"hello.cbl", "       IDENTIFICATION DIVISION.\n       PROGRAM-ID. HELLO.\n", "COBOL"},
+	})
+}
+
+func TestWindowsScriptHeuristics(t *testing.T) {
+	testDetect(t, []detectCase{
+		{"build.ps1", "Write-Host 'hi'\n", "PowerShell"},
+		{"Tools.psm1", "function Get-Tool {}\n", "PowerShell"},
+		{"Tools.psd1", "@{ ModuleVersion = '1.0' }\n", "PowerShell"},
+		{"build.bat", "@echo off\r\necho hi\r\n", "Batchfile"},
+		{"run.cmd", "@echo off\r\n", "Batchfile"},
+		// by contents alone
+		{"setup", "#Requires -Version 5.1\nWrite-Host 'hi'\n", "PowerShell"},
+		{"greet", "param([string]$Name)\nWrite-Output \"Hello $Name\"\n", "PowerShell"},
+		{"deploy", "#!/usr/bin/env pwsh\n$x = 1\n", "PowerShell"},
+		{"compile", "@echo off\r\nsetlocal\r\ncd %~dp0\r\n", "Batchfile"},
+	})
+}
+
+func TestSystemsLanguages(t *testing.T) {
+	testDetect(t, []detectCase{
+		{"main.zig", "const std = @import(\"std\");\n", "Zig"},
+		{"main.nim", "echo \"hi\"\n", "Nim"},
+		{"app.cr", "puts \"hi\"\n", "Crystal"},
+		// .v by contents
+		{"main.v", "fn main() {\n\tprintln('hi')\n}\n", "V"},
+		{"mod.v", "module main\n\nimport os\n\nfn main() {\n\tprintln(os.args)\n}\n", "V"},
+		{"counter.v", "module counter(input clk, output reg [3:0] q);\n  always @(posedge clk) q <= q + 1;\nendmodule\n", "Verilog"},
+		{"defs.v", "`timescale 1ns / 1ps\n`define WIDTH 8\n", "Verilog"},
+		{"Plus.v", "Theorem plus_0 : forall n, n + 0 = n.\nProof.\n  induction n; simpl; auto.\nQed.\n", "Coq"},
+		{"Lists.v", "Require Import List.\nInductive tree := Leaf | Node (l r : tree).\n", "Coq"},
+	})
+}
+
+func TestBEAMLanguages(t *testing.T) {
+	testDetect(t, []detectCase{
+		{"app.ex", "defmodule App do\n  def hi, do: :ok\nend\n", "Elixir"},
+		{"test.exs", "ExUnit.start()\n", "Elixir"},
+		{"server.erl", "-module(server).\n-export([start/0]).\n", "Erlang"},
+		{"state.hrl", "-record(state, {}).\n", "Erlang"},
+		{"index.html.heex", "<div><%= @name %></div>\n", "HTML+EEX"},
+		{"page.html.leex", "<%= @count %>\n", "HTML+EEX"},
+		{"page.html.eex", "<%= @count %>\n", "HTML+EEX"},
+		{"card.heex", "<.card title={@title} />\n", "HTML+EEX"},
+		{"hello.ex", "include std/io.e\nputs(1, \"hi\")\n", "Euphoria"},
+	})
+}
+
+func TestSmartContractLanguages(t *testing.T) {
+	testDetect(t, []detectCase{
+		{"Token.sol", "// SPDX-License-Identifier: MIT\npragma solidity ^0.8.0;\n\ncontract Token {\n}\n", "Solidity"},
+		{"Vault.sol", "abstract contract Vault is Ownable, Pausable {\n}\n", "Solidity"},
+		{"auction.vy", "# @version ^0.3.0\n\nbeneficiary: public(address)\n", "Vyper"},
+		{"coin.move", "module 0x1::coin {\n    struct Coin has store { value: u64 }\n}\n", "Move"},
+		// by contents alone
+		{"Token", "pragma solidity >=0.7.0 <0.9.0;\n\ncontract Token {\n}\n", "Solidity"},
+	})
+}
+
+func TestHDLLanguages(t *testing.T) {
+	testDetect(t, []detectCase{
+		{"fifo.sv", "module fifo(input logic clk);\nendmodule\n", "SystemVerilog"},
+		{"bus.svh", "interface bus;\n  logic valid;\nendinterface\n", "SystemVerilog"},
+		{"adder.vhd", "library ieee;\nuse ieee.std_logic_1164.all;\n\nentity adder is\nend adder;\n", "VHDL"},
+		{"adder.vhdl", "architecture rtl of adder is\nbegin\nend rtl;\n", "VHDL"},
+		// .v by contents
+		{"counter.v", "module counter(input logic clk, output logic [3:0] q);\n  always_ff @(posedge clk) q <= q + 1;\nendmodule\n", "SystemVerilog"},
+		// without an extension
+		{"rtl/fifo", "module fifo;\n  always_comb begin\n  end\nendmodule\n", "SystemVerilog"},
+		{"rtl/adder", "library IEEE;\nuse IEEE.numeric_std.all;\n", "VHDL"},
+		{"rtl/counter", "module counter(clk);\n  always @(posedge clk) q <= q + 1;\nendmodule\n", "Verilog"},
+	})
+}
+
+func TestJVMLanguages(t *testing.T) {
+	testDetect(t, []detectCase{
+		{"Main.scala", "object Main extends App {\n  println(\"hi\")\n}\n", "Scala"},
+		{"build.sc", "import mill._\n\nobject app extends ScalaModule\n", "Scala"},
+		{"hello.sc", "//> using scala 3\n@main def hello() = println(\"hi\")\n", "Scala"},
+		{"synth.sc", "SynthDef(\\sine, { Out.ar(0, SinOsc.ar(440)) }).add;\n~freq = 440;\n", "SuperCollider"},
+		{"core.clj", "(ns app.core)\n\n(defn hi [] :ok)\n", "Clojure"},
+		{"app.cljs", "(ns app.ui)\n", "Clojure"},
+		{"shared.cljc", "(ns app.shared)\n", "Clojure"},
+		{"deps.edn", "{:deps {org.clojure/clojure {:mvn/version \"1.11.1\"}}}\n", "edn"},
+		{"Tool.groovy", "class Tool {\n  def run() { println 'hi' }\n}\n", "Groovy"},
+		{"Main.kt", "fun main() {\n    println(\"hi\")\n}\n", "Kotlin"},
+	})
+}
+
+func TestHaskellLanguages(t *testing.T) {
+	testDetect(t, []detectCase{
+		{"Main.hs", "module Main where\n\nmain :: IO ()\nmain = putStrLn \"hi\"\n", "Haskell"},
+		{"Notes.lhs", "Some prose.\n\n> main :: IO ()\n> main = putStrLn \"hi\"\n", "Literate Haskell"},
+		{"Main.elm", "module Main exposing (main)\n\nimport Html\n", "Elm"},
+		{"Main.purs", "module Main where\n\nimport Prelude\n", "PureScript"},
+		{"Main.idr", "module Main\n\nmain : IO ()\n", "Idris"},
+		// without an extension
+		{"bin/main", "module Main where\n\nimport qualified Data.Map as M\n", "Haskell"},
+		{"bin/notes", "\\begin{code}\nmain = print 1\n\\end{code}\n", "Literate Haskell"},
+		{"src/Main", "module Main exposing (main)\n", "Elm"},
+		{"src/App", "module App where\n\nimport Prelude\nimport Effect.Console (log)\n", "PureScript"},
+	})
+}
+
+func TestJuliaHeuristics(t *testing.T) {
+	testDetect(t, []detectCase{
+		{"src/Solver.jl", "module Solver\n\nexport solve\n\nend\n", "Julia"},
+		// without an extension
+		{"bin/run", "using LinearAlgebra, Statistics\n\nx = rand(3)\n", "Julia"},
+		{"bin/solve", "function solve!(x::Vector{Float64})\n    x .= 0\nend\n", "Julia"},
+		{"bin/model", "mutable struct Model\n    n::Int\nend\n", "Julia"},
+		{"bin/bench", "@time sum(1:10)\n", "Julia"},
+	})
+	testNotDetected(t, "Julia", map[string]string{
+		"script": "function f() {\n  return 1;\n}\n",
+	})
+}
@@ -18,9 +18,12 @@ package linguist
 import (
 	"bufio"
 	"bytes"
+	"errors"
+	"fmt"
 	"log"
 	"path/filepath"
 	"regexp"
+	"strings"
 
 	"gopkg.in/yaml.v1"
 )
@@ -28,11 +31,22 @@ import (
 var (
 	extensions   = map[string][]string{}
 	filenames    = map[string][]string{}
+	foldedNames  = map[string][]string{} // filenames by lowercase name
 	interpreters = map[string][]string{}
 	colors       = map[string]string{}
+	groups       = map[string]string{}
+	types        = map[string]string{}
+	aliases      = map[string]string{}
 
 	shebangRE       = regexp.MustCompile(`^#!\s*(\S+)(?:\s+(\S+))?.*`)
+	languageHintRE  = regexp.MustCompile(`\blinguist:language=([\w+#.'-]+)`)
 	scriptVersionRE = regexp.MustCompile(`((?:\d+\.?)+)`)
+
+	// "-*- mode: ruby; coding: utf-8 -*-", or just "-*- ruby -*-"
+	emacsModelineRE = regexp.MustCompile(`-\*-(.*?)-\*-`)
+	emacsModeRE     = regexp.MustCompile(`(?i)(?:^|;)\s*mode\s*:\s*([\w+#.-]+)`)
+	// "vim: set ft=python:", "vi: filetype=sh" or "ex: syntax=ruby"
+	vimModelineRE = regexp.MustCompile(`(?i)(?:^|\s)(?:vim?|ex)(?:[<=>]?\d+)?:.*?\b(?:ft|filetype|syntax)=([\w+#.-]+)`)
 )
 
 func init() {
@@ -41,6 +55,9 @@ func init() {
 		Filenames    []string `yaml:"filenames,omitempty"`
 		Interpreters []string `yaml:"interpreters,omitempty"`
 		Color        string   `yaml:"color,omitempty"`
+		Group        string   `yaml:"group,omitempty"`
+		Type         string   `yaml:"type,omitempty"`
+		Aliases      []string `yaml:"aliases,omitempty"`
 	}
 	languages := map[string]*language{}
 
@@ -55,14 +72,37 @@ func init() {
 		}
 		for _, f := range l.Filenames {
 			filenames[f] = append(filenames[f], n)
+			if !hinted(foldedNames[strings.ToLower(f)], n) {
+				foldedNames[strings.ToLower(f)] = append(foldedNames[strings.ToLower(f)], n)
+			}
 		}
 		for _, i := range l.Interpreters {
 			interpreters[i] = append(interpreters[i], n)
 		}
 		colors[n] = l.Color
+		if l.Group != "" {
+			groups[n] = l.Group
+		}
+		types[n] = l.Type
+		// like github, every language may be called by its lowercase name
+		// with dashes for spaces, e.g. "protocol-buffer"
+		aliases[strings.ToLower(n)] = n
+		aliases[strings.ToLower(strings.Replace(n, " ", "-", -1))] = n
+		for _, a := range l.Aliases {
+			aliases[strings.ToLower(a)] = n
+		}
 	}
 }
 
+// Convenience function that returns the name of the language called alias,
+// e.g. "C++" for "cpp", from the aliases in the languages.yml file provided
+// by https://github.com/github/linguist, ignoring case.
+//
+// Returns the empty string for unknown languages.
+func LanguageByAlias(alias string) string {
+	return aliases[strings.ToLower(strings.TrimSpace(alias))]
+}
+
 // Convenience function that returns the color associated
 // with the language, in HTML Hex notation (e.g. "#123ABC")
 // from the languages.yml file provided by https://github.com/github/linguist
@@ -75,22 +115,68 @@ func LanguageColor(language string) string {
 	return ""
 }
 
+// Convenience function that returns the type of the language, one of
+// "programming", "markup", "data" or "prose"
+// from the languages.yml file provided by https://github.com/github/linguist
+//
+// Returns the empty string for unknown languages.
+func LanguageType(language string) string {
+	return types[language]
+}
+
+// Convenience function that returns the name of the parent language
+// a language is grouped under in statistics (e.g. "Shell" for "fish")
+// from the languages.yml file provided by https://github.com/github/linguist
+//
+// Returns language itself if it does not belong to a group.
+func LanguageGroup(language string) string {
+	if g, ok := groups[language]; ok {
+		return g
+	}
+	return language
+}
+
 // Attempts to determine the language of a source file based solely on 
 // common naming conventions and file extensions
 // from the languages.yml file provided by https://github.com/github/linguist
 //
+// filename may be a path, only its base name is looked up in the filenames
+// table, e.g. "config/.env.local" is Dotenv.
+//
+// Names and extensions are matched exactly first, then ignoring case, e.g.
+// "MAKEFILE" is Makefile and "MAIN.GO" is Go, but ".C" is C++ while ".c" is C.
+//
 // Returns the empty string in ambiguous or unrecognized cases.
 func LanguageByFilename(filename string) string {
-	if l := filenames[filename]; len(l) == 1 {
-		return l[0]
+	language, _ := languageByFilename(filename)
+	return language
+}
+
+// Strategies by which a language was determined, see FileInfo.
+const (
+	StrategyRule        = "rule"        // a rule added with Detector.AddRule
+	StrategyComment     = "comment"     // a linguist:language= comment
+	StrategyModeline    = "modeline"    // an Emacs or Vim modeline
+	StrategySniffing    = "sniffing"    // contents, in content priority mode
+	StrategyFilename    = "filename"    // the exact filename
+	StrategyExtension   = "extension"   // the file extension
+	StrategyInterpreter = "interpreter" // the interpreter in a shebang line
+	StrategyHeuristics  = "heuristics"  // content rules, e.g. for .h files
+	StrategyClassifier  = "classifier"  // see Analyse
+)
+
+func languageByFilename(filename string) (language, strategy string) {
+	// GitHub Actions workflows, whatever their contents look like
+	if workflowRE.MatchString(filepath.ToSlash(filename)) {
+		return "YAML", StrategyFilename
 	}
-	ext := filepath.Ext(filename)
-	if ext != "" {
-		if l := extensions[ext]; len(l) == 1 {
-			return l[0]
-		}
+	if l := languagesByName(filename); len(l) == 1 {
+		return l[0], StrategyFilename
 	}
-	return ""
+	if l := languagesByExtensions(filename); len(l) == 1 {
+		return l[0], StrategyExtension
+	}
+	return "", ""
 }
 
 // Attempts to detect all possible languages of a source file based solely on 
@@ -101,31 +187,253 @@ func LanguageByFilename(filename string) string {
 //
 // May return an empty slice.
 func LanguageHints(filename string) (hints []string) {
-	if l, ok := filenames[filename]; ok {
-		hints = append(hints, l...)
-	}
-	if ext := filepath.Ext(filename); ext != "" {
-		if l, ok := extensions[ext]; ok {
-			hints = append(hints, l...)
+	hints = append(hints, languagesByName(filename)...)
+	// dotfiles such as .vimrc are often listed both as filename and extension
+	for _, l := range languagesByExtensions(filename) {
+		if !hinted(hints, l) {
+			hints = append(hints, l)
 		}
 	}
 	return hints
 }
 
+// Looks up the extensions of filename longest first, e.g. ".cmake.in" before
+// ".in" for "config.cmake.in", returning the languages of the first listed.
+func languagesByExtensions(filename string) []string {
+	base := filepath.Base(filename)
+	for i := 0; i < len(base); i++ {
+		if base[i] != '.' {
+			continue
+		}
+		if l := languagesByExtension(base[i:]); len(l) > 0 {
+			return l
+		}
+	}
+	return nil
+}
+
+// Looks up the base name of filename as given, falling back to ignoring case,
+// e.g. for "MAKEFILE" from a case-insensitive filesystem, so that the few
+// names which languages.yml lists in more than one case still match exactly.
+func languagesByName(filename string) []string {
+	base := filepath.Base(filename)
+	if l, ok := filenames[base]; ok {
+		return l
+	}
+	return foldedNames[strings.ToLower(base)]
+}
+
+// Looks up ext as given, falling back to lowercase for extensions such as
+// ".S" (preprocessed assembly) which languages.yml only lists in lowercase.
+//
+// Like filenames, extensions which languages.yml lists in more than one case
+// match exactly first, e.g. ".C" is C++ while ".c" is C.
+func languagesByExtension(ext string) []string {
+	if l, ok := extensions[ext]; ok {
+		return l
+	}
+	return extensions[strings.ToLower(ext)]
+}
+
 // Attempts to detect the language of a source file based on its
 // contents and a slice of hints to the possible answer.
 //
+// The interpreter named by a shebang line is checked first, followed by
+// simple content rules (e.g. telling fish scripts from other shell scripts),
+// and finally the classifier.
+//
 // Obtain hints with LanguageHints()
 //
+// Contents which are not valid UTF-8 are assumed to be in a single byte
+// encoding, see Encoding, and are transcoded first.
+//
 // Returns the empty string a language could not be determined.
 func LanguageByContents(contents []byte, hints []string) string {
+	language, _ := languageByContents(contents, hints, 0, 0)
+	return language
+}
+
+func languageByContents(contents []byte, hints []string, maxCandidates, maxTokens int) (language, strategy string) {
+	contents = normalizeEOL(toUTF8(contents))
 	interpreter := detectInterpreter(contents)
 	if interpreter != "" {
 		if l := interpreters[interpreter]; len(l) == 1 {
-			return l[0]
+			return l[0], StrategyInterpreter
+		}
+	}
+	if l := languageByHeuristics(contents, hints); l != "" {
+		return l, StrategyHeuristics
+	}
+	if l := analyse(contents, hints, maxCandidates, maxTokens); l != "" {
+		return l, StrategyClassifier
+	}
+	return "", ""
+}
+
+// Returned by DetectFromContent for binary contents, see IsBinary.
+var ErrBinary = errors.New("linguist: binary contents")
+
+// Attempts to detect the language of contents alone, for when there is no
+// filename at all, e.g. a pasted snippet.
+//
+// A linguist:language= comment is checked first (see LanguageByComment),
+// then an editor modeline (see LanguageByModeline), then the interpreter
+// named by a shebang line, then contents are sniffed
+// for data such as CSV, followed by simple content rules, and
+// finally the classifier. Having no hints, the classifier has to choose among
+// every language, so its answer is only used if it is a clear winner.
+//
+// Returns the empty string if a language could not be determined with some
+// confidence, or ErrBinary if contents are binary.
+func DetectFromContent(contents []byte) (string, error) {
+	if IsBinary(contents) {
+		return "", ErrBinary
+	}
+	contents = normalizeEOL(toUTF8(contents))
+	if l := LanguageByComment(contents); l != "" {
+		return l, nil
+	}
+	if l := LanguageByModeline(contents); l != "" {
+		return l, nil
+	}
+	if l := interpreters[detectInterpreter(contents)]; len(l) == 1 {
+		return l[0], nil
+	}
+	if l := languageBySniffing(contents, nil); l != "" {
+		return l, nil
+	}
+	if l := languageByHeuristics(contents, nil); l != "" {
+		return l, nil
+	}
+	return analyseConfident(contents), nil
+}
+
+// Like Detector.Detect, only ever choosing one of candidates, when it is known
+// that the file is written in one of them. Candidates may be given by name or
+// alias, see LanguageByAlias.
+//
+// The languages suggested by filename which are among candidates are used as
+// hints, or all of candidates if there are none, and the interpreter in a
+// shebang line and the classifier only choose among them. Content rules are
+// tried as by Detect, only picking a language among candidates.
+//
+// Returns the empty string if a language could not be determined, ErrBinary
+// if contents are binary, or an error if a candidate is not a known language.
+func DetectAmong(filename string, contents []byte, candidates []string) (string, error) {
+	var among []string
+	for _, c := range candidates {
+		language := LanguageByAlias(c)
+		if language == "" {
+			return "", fmt.Errorf("linguist: unknown language %q", c)
+		}
+		among = append(among, language)
+	}
+	if IsBinary(contents) {
+		return "", ErrBinary
+	}
+	if language := languagesByName(filename); len(language) == 1 && hinted(among, language[0]) {
+		return language[0], nil
+	}
+	var hints []string
+	named := LanguageHints(filename)
+	for _, l := range named {
+		if hinted(among, l) {
+			hints = append(hints, l)
+		}
+	}
+	if len(hints) == 1 {
+		return hints[0], nil
+	}
+	if len(hints) == 0 {
+		hints = among
+	}
+	contents = normalizeEOL(toUTF8(contents))
+	var interpreted []string
+	for _, l := range interpreters[detectInterpreter(contents)] {
+		if hinted(hints, l) {
+			interpreted = append(interpreted, l)
+		}
+	}
+	if len(interpreted) == 1 {
+		return interpreted[0], nil
+	}
+	// heuristics see every language suggested by filename, so that rules
+	// shared with languages beyond candidates still apply, and only the
+	// language they pick must be one of candidates
+	if len(named) == 0 {
+		named = among
+	}
+	if l := languageByHeuristicsAmong(contents, named, among); l != "" {
+		return l, nil
+	}
+	return analyse(contents, hints, 0, 0), nil
+}
+
+// Returns the language named by a comment such as "# linguist:language=Cython"
+// or "// linguist:language=protocol-buffer" on the first line of contents, or
+// the second after a shebang line, like an editor modeline. The language may
+// be given by name or alias, see LanguageByAlias, using dashes for spaces.
+//
+// Returns the empty string if there is no such comment, or the language is
+// unknown.
+func LanguageByComment(contents []byte) string {
+	lines := bytes.SplitN(contents, []byte("\n"), 3)
+	if len(lines) > 2 {
+		lines = lines[:2]
+	}
+	for i, line := range lines {
+		if i > 0 && !bytes.HasPrefix(lines[0], []byte("#!")) {
+			break
+		}
+		if m := languageHintRE.FindSubmatch(line); m != nil {
+			return LanguageByAlias(string(m[1]))
 		}
 	}
-	return Analyse(contents, hints)
+	return ""
+}
+
+// Returns the language set by an editor modeline: an Emacs one such as
+// "-*- mode: ruby -*-" on the first line of contents, or the second after a
+// shebang line, or a Vim one such as "vim: set ft=python:" in the first or
+// last five lines. The mode is looked up by name or alias, see
+// LanguageByAlias.
+//
+// Returns the empty string if there is no such modeline, or the language is
+// unknown.
+func LanguageByModeline(contents []byte) string {
+	lines := bytes.Split(bytes.TrimSuffix(contents, []byte("\n")), []byte("\n"))
+	for i, line := range lines {
+		if i > 1 || (i > 0 && !bytes.HasPrefix(lines[0], []byte("#!"))) {
+			break
+		}
+		m := emacsModelineRE.FindSubmatch(line)
+		if m == nil {
+			continue
+		}
+		mode := bytes.TrimSpace(m[1])
+		if bytes.Contains(mode, []byte(":")) {
+			if m = emacsModeRE.FindSubmatch(mode); m == nil {
+				continue
+			}
+			mode = m[1]
+		}
+		if language := LanguageByAlias(string(mode)); language != "" {
+			return language
+		}
+	}
+
+	edges := lines
+	if len(lines) > 10 {
+		edges = append(append([][]byte{}, lines[:5]...), lines[len(lines)-5:]...)
+	}
+	for _, line := range edges {
+		if m := vimModelineRE.FindSubmatch(line); m != nil {
+			if language := LanguageByAlias(string(m[1])); language != "" {
+				return language
+			}
+		}
+	}
+	return ""
 }
 
 func detectInterpreter(contents []byte) string {
@@ -0,0 +1,249 @@
+package linguist
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDetectAmong(t *testing.T) {
+	for _, tt := range []struct {
+		filename   string
+		contents   string
+		candidates []string
+		want       string
+	}{
+		// the .h fallback rule is shared with Objective-C
+		{"x.h", "int x;\n", []string{"C", "C++"}, "C"},
+		{"x.h", "#include <vector>\nstd::vector<int> v;\n", []string{"C", "C++"}, "C++"},
+		{"x.h", "@interface Foo\n@end\n", []string{"C", "C++", "Objective-C"}, "Objective-C"},
+		// a single candidate suggested by the name wins
+		{"main.go", "package main\n", []string{"Go", "Python"}, "Go"},
+		// by alias
+		{"script", "#!/usr/bin/env python\nprint(1)\n", []string{"python3", "ruby"}, "Python"},
+	} {
+		got, err := DetectAmong(tt.filename, []byte(tt.contents), tt.candidates)
+		if err != nil {
+			t.Errorf("DetectAmong(%q, %v): %v", tt.filename, tt.candidates, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("DetectAmong(%q, %v) = %q, want %q", tt.filename, tt.candidates, got, tt.want)
+		}
+		if detected := (&Detector{}).Detect(tt.filename, []byte(tt.contents)); hinted(tt.candidates, detected) && detected != got {
+			t.Errorf("DetectAmong(%q, %v) = %q, but Detect = %q", tt.filename, tt.candidates, got, detected)
+		}
+	}
+}
+
+func TestDetectAmongErrors(t *testing.T) {
+	if _, err := DetectAmong("x.c", []byte("int x;\n"), []string{"NoSuchLanguage"}); err == nil {
+		t.Error("DetectAmong with an unknown candidate: no error")
+	}
+	if _, err := DetectAmong("x.c", []byte("\x00\x01\x02\x03"), []string{"C"}); err != ErrBinary {
+		t.Errorf("DetectAmong of binary contents: err = %v, want ErrBinary", err)
+	}
+}
+
+func TestLanguageByFilenameDotfiles(t *testing.T) {
+	for filename, want := range map[string]string{
+		".env":              "Dotenv",
+		".env.local":        "Dotenv",
+		"config/.env.local": "Dotenv",
+		".envrc":            "Shell",
+		".editorconfig":     "EditorConfig",
+		"web/.editorconfig": "EditorConfig",
+	} {
+		if got := LanguageByFilename(filename); got != want {
+			t.Errorf("LanguageByFilename(%q) = %q, want %q", filename, got, want)
+		}
+	}
+}
+
+func TestLanguageByFilenameGradle(t *testing.T) {
+	for filename, want := range map[string]string{
+		"build.gradle":            "Groovy",
+		"app/build.gradle":        "Groovy",
+		"settings.gradle":         "Groovy",
+		"init.gradle":             "Groovy",
+		"build.gradle.kts":        "Kotlin",
+		"app/settings.gradle.kts": "Kotlin",
+		"script.kts":              "Kotlin",
+		// other .gradle files are still Gradle
+		"deps.gradle": "Gradle",
+	} {
+		if got := LanguageByFilename(filename); got != want {
+			t.Errorf("LanguageByFilename(%q) = %q, want %q", filename, got, want)
+		}
+	}
+}
+
+func TestLanguageByFilenameMakefiles(t *testing.T) {
+	for filename, want := range map[string]string{
+		"Makefile":        "Makefile",
+		"makefile":        "Makefile",
+		"GNUmakefile":     "Makefile",
+		"BSDmakefile":     "Makefile",
+		"rules.mk":        "Makefile",
+		"Makefile.in":     "Makefile",
+		"Makefile.am":     "Automake",
+		"sub/Makefile.am": "Automake",
+		"GNUmakefile.am":  "Automake",
+		"configure.ac":    "M4Sugar",
+		"configure.in":    "M4Sugar",
+	} {
+		if got := LanguageByFilename(filename); got != want {
+			t.Errorf("LanguageByFilename(%q) = %q, want %q", filename, got, want)
+		}
+	}
+}
+
+func TestDetectFromContent(t *testing.T) {
+	for _, tt := range []struct {
+		contents string
+		want     string
+	}{
+		{"import os\nimport sys\n\n\ndef main(argv):\n    for arg in argv[1:]:\n        print(os.path.abspath(arg))\n\n\nif __name__ == '__main__':\n    main(sys.argv)\n", "Python"},
+		{"#!/usr/bin/env ruby\nputs 1\n", "Ruby"},
+		{"a,b,c\n1,2,3\n4,5,6\n", "CSV"},
+		{"// vim: set ft=cpp:\n{\n  x = 1;\n}\n", "C++"},
+		// any of the curly-brace languages
+		{"{\n  x = 1;\n}\n", ""},
+		{"if (x) {\n  y();\n}\n", ""},
+		{"", ""},
+	} {
+		got, err := DetectFromContent([]byte(tt.contents))
+		if err != nil || got != tt.want {
+			t.Errorf("DetectFromContent(%q) = %q, %v, want %q", tt.contents, got, err, tt.want)
+		}
+	}
+	if _, err := DetectFromContent([]byte("\x00\x01\x02\x03")); err != ErrBinary {
+		t.Errorf("DetectFromContent of binary contents: err = %v, want ErrBinary", err)
+	}
+}
+
+func TestLanguageByFilenameBuildSystems(t *testing.T) {
+	for filename, want := range map[string]string{
+		"CMakeLists.txt":     "CMake",
+		"src/CMakeLists.txt": "CMake",
+		"FindFoo.cmake":      "CMake",
+		// the longest extension wins
+		"config.cmake.in":   "CMake",
+		"meson.build":       "Meson",
+		"meson.options":     "Meson",
+		"meson_options.txt": "Meson",
+		"BUILD":             "Starlark",
+		"BUILD.bazel":       "Starlark",
+		"WORKSPACE":         "Starlark",
+		"MODULE.bazel":      "Starlark",
+		"defs.bzl":          "Starlark",
+	} {
+		if got := LanguageByFilename(filename); got != want {
+			t.Errorf("LanguageByFilename(%q) = %q, want %q", filename, got, want)
+		}
+	}
+}
+
+func TestLanguageByFilenameEditors(t *testing.T) {
+	for filename, want := range map[string]string{
+		"plugin/foo.vim": "Vim Script",
+		".vimrc":         "Vim Script",
+		"home/.vimrc":    "Vim Script",
+		"_vimrc":         "Vim Script",
+		".gvimrc":        "Vim Script",
+		"lisp/config.el": "Emacs Lisp",
+		"init.el":        "Emacs Lisp",
+		".emacs":         "Emacs Lisp",
+	} {
+		if got := LanguageByFilename(filename); got != want {
+			t.Errorf("LanguageByFilename(%q) = %q, want %q", filename, got, want)
+		}
+		// listed both as filename and extension, but hinted once
+		if hints := LanguageHints(filename); len(hints) != 1 || hints[0] != want {
+			t.Errorf("LanguageHints(%q) = %v, want [%s]", filename, hints, want)
+		}
+	}
+}
+
+func TestProseLanguages(t *testing.T) {
+	for filename, want := range map[string]string{
+		"api.rst":         "reStructuredText",
+		"design.adoc":     "AsciiDoc",
+		"design.asciidoc": "AsciiDoc",
+		"notes.org":       "Org",
+	} {
+		if got := LanguageByFilename(filename); got != want {
+			t.Errorf("LanguageByFilename(%q) = %q, want %q", filename, got, want)
+		}
+		if got := LanguageType(want); got != "prose" {
+			t.Errorf("LanguageType(%q) = %q, want prose", want, got)
+		}
+	}
+}
+
+func TestLanguageByFilenameCase(t *testing.T) {
+	for filename, want := range map[string]string{
+		"MAKEFILE":     "Makefile",
+		"makefile":     "Makefile",
+		"src/MakeFile": "Makefile",
+		"MAIN.GO":      "Go",
+		"Script.PY":    "Python",
+		// listed in more than one case, so matched exactly
+		"main.C":  "C++",
+		"main.c":  "C",
+		"Main.CC": "C++",
+	} {
+		if got := LanguageByFilename(filename); got != want {
+			t.Errorf("LanguageByFilename(%q) = %q, want %q", filename, got, want)
+		}
+	}
+}
+
+func TestLanguageByModeline(t *testing.T) {
+	padding := strings.Repeat("x = 1\n", 10)
+	for _, tt := range []struct {
+		contents string
+		want     string
+	}{
+		{"# -*- mode: ruby -*-\nputs 1\n", "Ruby"},
+		{"/* -*- Mode: C++; indent-tabs-mode: nil -*- */\n", "C++"},
+		{";; -*- coding: utf-8; mode: emacs-lisp -*-\n", "Emacs Lisp"},
+		{"# -*- python -*-\n", "Python"},
+		{"#!/bin/sh\n# -*- mode: ruby -*-\n", "Ruby"},
+		{"# vim: set ft=python:\n", "Python"},
+		{"// vim:filetype=javascript\n", "JavaScript"},
+		{"# vi: syntax=sh\n", "Shell"},
+		{padding + "# vim: set ft=ruby sw=2:\n", "Ruby"},
+		// Emacs modelines only at the top, Vim ones in the first or last five lines
+		{"x = 1\n# -*- mode: ruby -*-\n", ""},
+		{"# -*- coding: utf-8 -*-\n", ""},
+		{padding + "# vim: set ft=ruby:\n" + padding, ""},
+		{"# envim: ft=ruby\n", ""},
+		{"# vim: set ft=nosuchlanguage:\n", ""},
+		{"", ""},
+	} {
+		if got := LanguageByModeline([]byte(tt.contents)); got != tt.want {
+			t.Errorf("LanguageByModeline(%q) = %q, want %q", tt.contents, got, tt.want)
+		}
+	}
+}
+
+func TestLanguageByComment(t *testing.T) {
+	for _, tt := range []struct {
+		contents string
+		want     string
+	}{
+		{"# linguist:language=Cython\ndef f(): pass\n", "Cython"},
+		{"// linguist:language=protocol-buffer\nmessage M {}\n", "Protocol Buffer"},
+		{"-- linguist:language=plpgsql\n", "PLpgSQL"},
+		{"#!/usr/bin/env python\n# linguist:language=Cython\n", "Cython"},
+		// only at the top of the file
+		{"x = 1\n# linguist:language=Cython\n", ""},
+		{"#!/bin/sh\n\n# linguist:language=Cython\n", ""},
+		{"# linguist:language=NoSuchLanguage\n", ""},
+		{"", ""},
+	} {
+		if got := LanguageByComment([]byte(tt.contents)); got != tt.want {
+			t.Errorf("LanguageByComment(%q) = %q, want %q", tt.contents, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package linguist
+
+import (
+	"io"
+	"os"
+)
+
+// Reads up to the first 512 bytes of the file at path, which is as much
+// as IgnoreReason and LanguageByContents take into account.
+func ReadHead(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	contents := make([]byte, 512)
+	n, err := io.ReadFull(f, contents)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	return contents[:n], nil
+}
+
+// Determines the programming language making up the most bytes of the files
+// in the directory tree rooted at root, i.e. "what language is this project".
+//
+// The tree is scanned with DefaultOptions, the same way cmd/l scans it: files
+// which should be ignored according to IgnoreReason, .gitignore or
+// .gitattributes are skipped, as are .git directories and symbolic links, see
+// Scanner. Languages of any type other than "programming" (see LanguageType)
+// are not considered.
+//
+// Returns the empty string if no programming language could be found.
+func PrimaryLanguage(root string) (string, error) {
+	return (&Detector{}).PrimaryLanguage(root)
+}
+
+// Like the PrimaryLanguage function, using d to detect languages.
+func (d *Detector) PrimaryLanguage(root string) (string, error) {
+	options, err := DefaultOptions(root)
+	if err != nil {
+		return "", err
+	}
+	options.Detector = d
+	result, err := NewScanner(root, options).Scan()
+	if err != nil {
+		return "", err
+	}
+	return result.PrimaryLanguage(), nil
+}
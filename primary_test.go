@@ -0,0 +1,54 @@
+package linguist
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestPrimaryLanguage(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		".git/HEAD":            "ref: refs/heads/main\n",
+		".gitignore":           "out/\n",
+		".gitattributes":       "gen/*.py linguist-generated\n",
+		"main.go":              "package main\n\nfunc main() {}\n",
+		"cmd/tool/main.go":     "package main\n\nimport \"fmt\"\n\nfunc main() { fmt.Println() }\n",
+		"vendor/lib/lib.rb":    strings.Repeat("puts 1\n", 1000),
+		"out/bundle.js":        strings.Repeat("var x = 1;\n", 1000),
+		"gen/api.py":           strings.Repeat("x = 1\n", 1000),
+		"docs/guide/intro.rb":  strings.Repeat("puts 1\n", 1000),
+		"testdata/large.json":  "{" + strings.Repeat(`"k": 1, `, 1000) + `"k": 1}`,
+		"config/settings.yaml": strings.Repeat("key: value\n", 1000),
+	}
+	for i := 0; i < 20; i++ {
+		files[fmt.Sprintf("data/%d.csv", i)] = strings.Repeat("a,b,c\n", 100)
+	}
+	writeTree(t, root, files)
+
+	got, err := PrimaryLanguage(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "Go" {
+		t.Errorf("PrimaryLanguage = %q, want Go", got)
+	}
+}
+
+func TestPrimaryLanguageNone(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"README.md":   "# readme\n",
+		"config.yaml": "key: value\n",
+	})
+	got, err := PrimaryLanguage(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "" {
+		t.Errorf("PrimaryLanguage = %q, want none", got)
+	}
+	if _, err := PrimaryLanguage(root + "/missing"); err == nil {
+		t.Error("PrimaryLanguage of a missing directory: no error")
+	}
+}
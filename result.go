@@ -0,0 +1,101 @@
+package linguist
+
+import "sort"
+
+// Statistics for one language in a Result.
+type LanguageStat struct {
+	Name    string  `json:"name"`
+	Size    int     `json:"size"`
+	Files   int     `json:"files"`
+	Percent float64 `json:"percent"` // of Result.TotalSize
+}
+
+// The languages making up a set of files, e.g. as found by Scanner.Scan.
+//
+// Files whose language could not be determined are counted by Scanner as
+// UnknownLanguage.
+type Result struct {
+	// Sorted by size, largest first, ties broken by name.
+	Languages    []LanguageStat `json:"languages"`
+	TotalSize    int            `json:"total_size"`
+	TotalFiles   int            `json:"total_files"`
+	IgnoredPaths int            `json:"ignored_paths"`
+
+	// The number of paths Scanner skipped because they could not be read.
+	Unreadable int `json:"unreadable_paths,omitempty"`
+
+	// Whether Scanner stopped early because of Options.MaxFiles, leaving
+	// out files which would have been counted.
+	Sampled bool `json:"sampled,omitempty"`
+}
+
+// Returns the Result for the given bytes and numbers of files of each
+// language, computing the totals and percentages. Languages missing from
+// files are counted with no files, e.g. for bytes embedded in other files.
+func NewResult(sizes map[string]int, files map[string]int, ignoredPaths int) Result {
+	r := Result{IgnoredPaths: ignoredPaths}
+	for name, size := range sizes {
+		r.Languages = append(r.Languages, LanguageStat{Name: name, Size: size, Files: files[name]})
+		r.TotalSize += size
+		r.TotalFiles += files[name]
+	}
+	for i := range r.Languages {
+		if r.TotalSize > 0 {
+			r.Languages[i].Percent = float64(r.Languages[i].Size) / float64(r.TotalSize) * 100
+		}
+	}
+	sort.Slice(r.Languages, func(i, j int) bool {
+		a, b := r.Languages[i], r.Languages[j]
+		if a.Size != b.Size {
+			return a.Size > b.Size
+		}
+		return a.Name < b.Name
+	})
+	return r
+}
+
+// Returns the statistics of language in r, or nil if it has none.
+func (r Result) Language(language string) *LanguageStat {
+	for i := range r.Languages {
+		if r.Languages[i].Name == language {
+			return &r.Languages[i]
+		}
+	}
+	return nil
+}
+
+// Returns the share of language in r, as a percentage of TotalSize.
+func (r Result) Percent(language string) float64 {
+	if stat := r.Language(language); stat != nil {
+		return stat.Percent
+	}
+	return 0
+}
+
+// Returns a copy of r with only the languages for which keep returns true,
+// e.g. to drop data languages or those making up less than 2%. TotalSize,
+// TotalFiles and percentages are recomputed from the remaining languages, so
+// that their percentages again add up to 100.
+func (r Result) Filter(keep func(language string, size int) bool) Result {
+	sizes, files := map[string]int{}, map[string]int{}
+	for _, stat := range r.Languages {
+		if keep(stat.Name, stat.Size) {
+			sizes[stat.Name] = stat.Size
+			files[stat.Name] = stat.Files
+		}
+	}
+	filtered := NewResult(sizes, files, r.IgnoredPaths)
+	filtered.Unreadable, filtered.Sampled = r.Unreadable, r.Sampled
+	return filtered
+}
+
+// Returns the language of type "programming" (see LanguageType) with the
+// most bytes in r, ties broken by name, or the empty string if there is none.
+func (r Result) PrimaryLanguage() string {
+	for _, stat := range r.Languages {
+		if LanguageType(stat.Name) == "programming" {
+			return stat.Name
+		}
+	}
+	return ""
+}
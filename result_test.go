@@ -0,0 +1,84 @@
+package linguist
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestNewResult(t *testing.T) {
+	// CSS has bytes embedded in other files, but no files of its own
+	r := NewResult(
+		map[string]int{"Go": 3000, "YAML": 500, "Shell": 500, "CSS": 1000},
+		map[string]int{"Go": 3, "YAML": 1, "Shell": 2},
+		7,
+	)
+	want := Result{
+		Languages: []LanguageStat{
+			{"Go", 3000, 3, 60},
+			{"CSS", 1000, 0, 20},
+			// ties are broken by name
+			{"Shell", 500, 2, 10},
+			{"YAML", 500, 1, 10},
+		},
+		TotalSize:    5000,
+		TotalFiles:   6,
+		IgnoredPaths: 7,
+	}
+	if !reflect.DeepEqual(r, want) {
+		t.Errorf("NewResult: got\n%+v, want\n%+v", r, want)
+	}
+	if r.Language("Go").Files != 3 || r.Language("Rust") != nil || r.Percent("Shell") != 10 || r.Percent("Rust") != 0 {
+		t.Errorf("Language and Percent: got %+v", r)
+	}
+	if got := r.PrimaryLanguage(); got != "Go" {
+		t.Errorf("PrimaryLanguage = %q, want Go", got)
+	}
+
+	data, err := json.Marshal(NewResult(map[string]int{"Go": 10}, map[string]int{"Go": 1}, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"languages":[{"name":"Go","size":10,"files":1,"percent":100}],"total_size":10,"total_files":1,"ignored_paths":0}`; string(data) != want {
+		t.Errorf("JSON: got %s, want %s", data, want)
+	}
+
+	// no bytes at all
+	if empty := NewResult(map[string]int{"Go": 0}, map[string]int{"Go": 1}, 0); empty.Languages[0].Percent != 0 || empty.PrimaryLanguage() != "Go" {
+		t.Errorf("NewResult of an empty file: got %+v", empty)
+	}
+}
+
+func TestResultFilter(t *testing.T) {
+	r := NewResult(
+		map[string]int{"Go": 6000, "Python": 2000, "JSON": 1500, "Shell": 100},
+		map[string]int{"Go": 30, "Python": 5, "JSON": 10, "Shell": 1},
+		4,
+	)
+	filtered := r.Filter(func(language string, size int) bool {
+		return LanguageType(language) == "programming" && size*100 >= r.TotalSize*2
+	})
+
+	if len(filtered.Languages) != 2 || filtered.Languages[0].Name != "Go" || filtered.Languages[1].Name != "Python" {
+		t.Fatalf("Filter: got %+v, want Go and Python", filtered.Languages)
+	}
+	if filtered.TotalSize != 8000 || filtered.TotalFiles != 35 || filtered.IgnoredPaths != 4 {
+		t.Errorf("Filter: got totals %d bytes, %d files, %d ignored, want 8000, 35, 4", filtered.TotalSize, filtered.TotalFiles, filtered.IgnoredPaths)
+	}
+	sum := 0.0
+	for _, stat := range filtered.Languages {
+		sum += stat.Percent
+	}
+	if math.Abs(sum-100) > 1e-9 || filtered.Percent("Go") != 75 {
+		t.Errorf("Filter: percentages %+v add up to %v, want 100 with Go at 75", filtered.Languages, sum)
+	}
+	// r itself is unchanged
+	if len(r.Languages) != 4 || r.Percent("Go") != 6000.0/9600*100 {
+		t.Errorf("Filter changed the original: %+v", r.Languages)
+	}
+
+	if none := r.Filter(func(string, int) bool { return false }); len(none.Languages) != 0 || none.TotalSize != 0 {
+		t.Errorf("Filter of everything: got %+v", none)
+	}
+}
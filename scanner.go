@@ -0,0 +1,712 @@
+package linguist
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The language Scanner counts files towards when theirs could not be
+// determined.
+const UnknownLanguage = "(unknown)"
+
+// Further reasons for which Scanner ignores files, besides those returned by
+// IgnoreReason, see ScannedFile.
+const (
+	IgnoredEmpty  = "empty"         // see Options.IgnoreEmpty
+	IgnoredExport = "export-ignore" // see Options.RespectExportIgnore
+	IgnoredTest   = "test"          // see Options.ExcludeTests
+	IgnoredOption = "option"        // see Options.Ignore
+)
+
+// A file or directory in a tree walked by a Scanner, see WalkFunc.
+type Entry struct {
+	// Relative to the root of the tree, separated by slashes.
+	Path  string
+	IsDir bool
+
+	// The size of a file in bytes.
+	Size int
+
+	// Returns at least the start of a file (see ReadHead), or all of it if
+	// full is true.
+	Read func(full bool) ([]byte, error)
+
+	// Set if the entry could not be read, e.g. a directory which could not
+	// be listed, in which case it is counted as unreadable and skipped.
+	Err error
+}
+
+// Walks a tree for a Scanner, calling visit for every file and directory in
+// it other than the root, directories before their contents. If visit
+// returns filepath.SkipDir for a directory, its contents are skipped, while
+// any other error stops the walk and is returned.
+type WalkFunc func(visit func(Entry) error) error
+
+// Returns a WalkFunc for the directory tree rooted at root, skipping .git
+// directories, symbolic links and anything else which is neither a regular
+// file nor a directory.
+//
+// Walking fails if root itself cannot be read.
+func WalkDir(root string) WalkFunc {
+	return func(visit func(Entry) error) error {
+		return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if path == root {
+				return err
+			}
+			rel, rerr := filepath.Rel(root, path)
+			if rerr != nil {
+				return rerr
+			}
+			entry := Entry{Path: filepath.ToSlash(rel)}
+			switch {
+			case err != nil:
+				entry.IsDir = info != nil && info.IsDir()
+				entry.Err = err
+			case info.IsDir():
+				if info.Name() == ".git" {
+					return filepath.SkipDir
+				}
+				entry.IsDir = true
+			case info.Mode().IsRegular():
+				entry.Size = int(info.Size())
+				entry.Read = func(full bool) ([]byte, error) {
+					if full {
+						return ioutil.ReadFile(path)
+					}
+					return ReadHead(path)
+				}
+			default:
+				return nil
+			}
+			return visit(entry)
+		})
+	}
+}
+
+// Options configure a Scanner, the zero value scans every file which should
+// not be ignored (see IgnoreReason) one at a time.
+type Options struct {
+	// Used to detect languages, the zero Detector if nil.
+	Detector *Detector
+
+	// The tree to scan, WalkDir of the Scanner's Root if nil.
+	Walk WalkFunc
+
+	// The names of gitignore(5) style files, e.g. ".gitignore" or ".ignore",
+	// read from every directory on the way, whose patterns apply below that
+	// directory. Only for directory trees, as they are read below Root.
+	IgnoreFiles []string
+
+	// The gitattributes(5) applying to the tree, see GitAttributes. May be nil.
+	Attributes *GitAttributes
+
+	// Skip files with the export-ignore attribute.
+	RespectExportIgnore bool
+
+	// Skip test files, see IsTest, or those matching any of TestPatterns
+	// instead, if given.
+	ExcludeTests bool
+	TestPatterns []*regexp.Regexp
+
+	// Reports whether a path, relative to the root of the scan and separated
+	// by slashes, should be skipped in addition to those that should be
+	// ignored. May be nil.
+	Ignore func(path string, isDir bool) bool
+
+	// Do not skip vendored files and documentation, based on filename.
+	UnignoreFilenames bool
+
+	// Do not skip binary and generated files, based on contents.
+	UnignoreContents bool
+
+	// Do not skip generated files, even without UnignoreContents.
+	CountGenerated bool
+
+	// Empty files never count towards any language; with IgnoreEmpty they
+	// are counted as ignored, rather than not at all.
+	IgnoreEmpty bool
+
+	// Do not descend into directories this many levels deep, e.g. 1 to scan
+	// the files in the root only, if greater than 0.
+	MaxDepth int
+
+	// Stop once this many files have been counted, if greater than 0, see
+	// Result.Sampled.
+	MaxFiles int
+
+	// The number of files read concurrently, and the number of files
+	// classified concurrently, each 1 if less than 1. Up to ReadBuffer files
+	// are queued to be read, and up to ClassifyBuffer files which were read
+	// are queued to be classified, e.g. so that reading can run ahead of
+	// slower classification.
+	ReadThreads    int
+	Threads        int
+	ReadBuffer     int
+	ClassifyBuffer int
+
+	// Give up detecting the language of a file after this long, counting
+	// it as UnknownLanguage, if greater than 0. Detection is not cancelled
+	// but left to finish in the background, which may outlast Scan; once
+	// Threads detections are left running, the next timeout waits for one
+	// of them to finish.
+	Timeout time.Duration
+
+	// Count at most this many bytes of any single file, if greater than 0.
+	// The bytes of a file split between languages are capped as a whole, in
+	// proportion to each language's share.
+	CapFileSize int
+
+	// If not nil, the bytes counted of every file, after CapFileSize, are
+	// multiplied by the weight of its path, e.g. to count recent changes
+	// for more.
+	Weight func(path string) float64
+
+	// Count the scripts and stylesheets embedded in HTML files towards their
+	// own languages, see SplitHTML.
+	SplitEmbedded bool
+
+	// Count the front matter of prose and markup files, such as Markdown,
+	// towards its own language, see FrontMatter.
+	SplitFrontMatter bool
+
+	// If not nil, files are neither read nor counted; instead List is called
+	// with the path of every file which would be, in the order walked.
+	List func(path string)
+
+	// If not nil, called for every file which was counted, ignored or
+	// could not be read, and for every directory which was ignored or could
+	// not be read, one at a time.
+	Report func(ScannedFile)
+}
+
+// A file or directory found by a Scanner, see Options.Report.
+type ScannedFile struct {
+	// Relative to the root of the scan, separated by slashes.
+	Path  string
+	IsDir bool
+
+	// The size of the whole file in bytes.
+	Size int
+
+	// Why the file or directory was skipped, one of the Ignored* constants,
+	// or empty if it was not.
+	IgnoreReason string
+
+	// Whether the file is vendored or generated, whether it was skipped for
+	// that reason or not.
+	ThirdParty bool
+
+	// The language the file was counted towards, or empty if it was not.
+	Language string
+
+	// The bytes of each language making up the file, including Language, if
+	// it was split with SplitEmbedded or SplitFrontMatter, nil otherwise.
+	Sizes map[string]int
+
+	// The bytes counted towards each language, including Language, after
+	// CapFileSize and Weight, or nil if the file was not counted.
+	Counted map[string]int
+
+	// The start of the file, as read to classify it.
+	Head []byte
+
+	// The error reading the file or directory, which was skipped. A file
+	// which could not be read in full to be split is still counted as a
+	// whole, with Language set.
+	Err error
+}
+
+// A Scanner determines the languages making up a directory tree.
+type Scanner struct {
+	Root    string
+	Options Options
+}
+
+// Returns a Scanner for the directory tree rooted at root.
+func NewScanner(root string, options Options) *Scanner {
+	return &Scanner{Root: root, Options: options}
+}
+
+// Returns the Options for scanning the directory tree at root the way git
+// would see it: with the patterns from .gitignore files if root is a git
+// repository, and the attributes from root's gitattributes(5), see
+// ReadGitAttributes.
+func DefaultOptions(root string) (Options, error) {
+	var options Options
+	if _, err := os.Stat(filepath.Join(root, ".git")); err == nil {
+		options.IgnoreFiles = []string{".gitignore"}
+	}
+	attributes, err := ReadGitAttributes(root)
+	if err != nil {
+		return options, err
+	}
+	options.Attributes = attributes
+	return options, nil
+}
+
+// returned to the WalkFunc to stop once Options.MaxFiles have been counted
+var errSampleFull = errors.New("MaxFiles reached")
+
+// Walks the tree, classifying every file which should not be ignored,
+// including by post processors (see Detector.AddPostProcessor), and counting
+// those whose language could not be determined as UnknownLanguage.
+//
+// Files and directories which cannot be read are skipped and counted, see
+// Result.Unreadable. Returns the first other error walking the tree, if any.
+func (s *Scanner) Scan() (Result, error) {
+	sc := &scan{
+		Options: s.Options,
+		root:    s.Root,
+		d:       s.Options.Detector,
+		sizes:   map[string]int{},
+		files:   map[string]int{},
+	}
+	if sc.d == nil {
+		sc.d = &Detector{}
+	}
+	walk := s.Options.Walk
+	if walk == nil {
+		walk = WalkDir(s.Root)
+	}
+
+	sc.loadIgnoreFiles(".")
+	sc.start()
+	err := walk(sc.visit)
+	sc.finish()
+	if err == errSampleFull {
+		err = nil
+	}
+
+	result := NewResult(sc.sizes, sc.files, sc.ignored)
+	result.Unreadable = sc.unreadable
+	result.Sampled = sc.sampled
+	return result, err
+}
+
+// the state of a single Scanner.Scan
+type scan struct {
+	Options
+	root  string
+	d     *Detector
+	rules ignoreRules
+
+	// files waiting to be read, and to be classified once read
+	reads, classifies chan *scanJob
+	readers           sync.WaitGroup
+	classifiers       sync.WaitGroup
+
+	// holds a value for every detection running with a Timeout, including
+	// those given up on, see detect
+	detections chan struct{}
+
+	// guards everything below, and calls to Report
+	mu         sync.Mutex
+	sizes      map[string]int
+	files      map[string]int
+	ignored    int
+	unreadable int
+	counted    int
+	sampled    bool
+}
+
+// a file which could not be ignored by name, on its way to classify
+type scanJob struct {
+	ScannedFile
+	attrs map[string]string
+	read  func(full bool) ([]byte, error)
+}
+
+// start starts ReadThreads goroutines reading the start of queued files, and
+// Threads goroutines classifying them.
+//
+// Reading and classifying have different bottlenecks, so that e.g. a fast
+// disk can be kept busy without more CPU time than wanted for classification.
+func (sc *scan) start() {
+	readThreads, threads := sc.ReadThreads, sc.Threads
+	if readThreads < 1 {
+		readThreads = 1
+	}
+	if threads < 1 {
+		threads = 1
+	}
+	readBuffer, classifyBuffer := sc.ReadBuffer, sc.ClassifyBuffer
+	if readBuffer < 0 {
+		readBuffer = 0
+	}
+	if classifyBuffer < 0 {
+		classifyBuffer = 0
+	}
+	sc.reads = make(chan *scanJob, readBuffer)
+	sc.classifies = make(chan *scanJob, classifyBuffer)
+	// one for each goroutine classifying, and as many given up on
+	sc.detections = make(chan struct{}, 2*threads)
+	for i := 0; i < readThreads; i++ {
+		sc.readers.Add(1)
+		go func() {
+			defer sc.readers.Done()
+			for j := range sc.reads {
+				contents, err := j.read(false)
+				if err != nil {
+					sc.d.logf("%s: could not be read, skipping: %v", j.Path, err)
+					j.Err = err
+					sc.report(j.ScannedFile)
+					continue
+				}
+				j.Head = contents
+				sc.classifies <- j
+			}
+		}()
+	}
+	for i := 0; i < threads; i++ {
+		sc.classifiers.Add(1)
+		go func() {
+			defer sc.classifiers.Done()
+			for j := range sc.classifies {
+				sc.classify(j)
+			}
+		}()
+	}
+}
+
+// finish waits until every queued file has been classified.
+func (sc *scan) finish() {
+	close(sc.reads)
+	sc.readers.Wait()
+	close(sc.classifies)
+	sc.classifiers.Wait()
+}
+
+// loadIgnoreFiles reads the IgnoreFiles in dir, relative to the root
+func (sc *scan) loadIgnoreFiles(dir string) {
+	for _, name := range sc.IgnoreFiles {
+		if err := sc.rules.load(sc.d, sc.root, dir, name); err != nil {
+			p := name
+			if dir != "." {
+				p = dir + "/" + name
+			}
+			sc.d.logf("%s: could not be read, skipping: %v", p, err)
+			sc.report(ScannedFile{Path: p, Err: err})
+		}
+	}
+}
+
+// visit is passed to the WalkFunc, skipping the entries which should be
+// ignored by name and queueing the rest to be read and classified.
+//
+// Files are ignored, in order: if empty; if matched by IgnoreFiles or
+// Ignore; if export-ignore with RespectExportIgnore; if a test file with
+// ExcludeTests; or by name, as vendored or documentation, unless
+// UnignoreFilenames, where Attributes may override the latter.
+func (sc *scan) visit(e Entry) error {
+	if sc.isSampled() {
+		sc.d.logf("MaxFiles reached, stopping")
+		return errSampleFull
+	}
+	if e.Err != nil {
+		sc.d.logf("%s: could not be read, skipping: %v", e.Path, e.Err)
+		sc.report(ScannedFile{Path: e.Path, IsDir: e.IsDir, Err: e.Err})
+		if e.IsDir {
+			return filepath.SkipDir
+		}
+		return nil
+	}
+	if e.IsDir {
+		if reason := sc.ignoredPath(e.Path, true); reason != "" {
+			sc.d.logf("%s is ignored (%s), skipping", e.Path, reason)
+			sc.report(ScannedFile{Path: e.Path, IsDir: true, IgnoreReason: reason})
+			return filepath.SkipDir
+		}
+		if sc.MaxDepth > 0 && strings.Count(e.Path, "/")+1 >= sc.MaxDepth {
+			sc.d.logf("%s is below MaxDepth, skipping", e.Path)
+			return filepath.SkipDir
+		}
+		sc.loadIgnoreFiles(e.Path)
+		return nil
+	}
+
+	f := ScannedFile{Path: e.Path, Size: e.Size}
+	sc.d.logf("%s is %d bytes", f.Path, f.Size)
+	if f.Size == 0 {
+		sc.d.logf("%s is empty file, skipping", f.Path)
+		if sc.IgnoreEmpty {
+			f.IgnoreReason = IgnoredEmpty
+			sc.report(f)
+		}
+		return nil
+	}
+	if f.IgnoreReason = sc.ignoredPath(f.Path, false); f.IgnoreReason != "" {
+		sc.d.logf("%s is ignored (%s), skipping", f.Path, f.IgnoreReason)
+		sc.report(f)
+		return nil
+	}
+
+	attrs := sc.Attributes.For(f.Path)
+	reason := attrIgnoreReason(sc.d.FilenameIgnoreReason(f.Path), attrs, false)
+	f.ThirdParty = reason == IgnoredVendored
+	if reason != "" && !sc.UnignoreFilenames {
+		sc.d.logf("%s: filename should be ignored (%s), skipping", f.Path, reason)
+		f.IgnoreReason = reason
+		sc.report(f)
+		return nil
+	}
+
+	if sc.List != nil {
+		sc.List(f.Path)
+		return nil
+	}
+	sc.reads <- &scanJob{ScannedFile: f, attrs: attrs, read: e.Read}
+	return nil
+}
+
+// ignoredPath returns the reason path should be skipped regardless of its
+// contents and of UnignoreFilenames, if any
+func (sc *scan) ignoredPath(path string, isDir bool) string {
+	switch {
+	case sc.rules.match(path, isDir):
+		return IgnoredGitIgnore
+	case sc.Ignore != nil && sc.Ignore(path, isDir):
+		return IgnoredOption
+	case isDir:
+		return ""
+	case sc.RespectExportIgnore && sc.Attributes.ExportIgnored(path):
+		return IgnoredExport
+	case sc.ExcludeTests && sc.isTest(path):
+		return IgnoredTest
+	}
+	return ""
+}
+
+// isTest reports whether path should be skipped by ExcludeTests
+func (sc *scan) isTest(path string) bool {
+	if len(sc.TestPatterns) == 0 {
+		return IsTest(path)
+	}
+	for _, re := range sc.TestPatterns {
+		if re.MatchString(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// classify finishes visit once the start of the file has been read, ignoring
+// it by contents, as binary or generated, unless UnignoreContents, where
+// Attributes may override the built in detection.
+func (sc *scan) classify(j *scanJob) {
+	f, contents := j.ScannedFile, j.Head
+
+	reason := attrIgnoreReason(ContentsIgnoreReason(f.Path, contents), j.attrs, true)
+	f.ThirdParty = f.ThirdParty || reason == IgnoredGenerated
+	if reason == IgnoredGenerated && sc.CountGenerated {
+		sc.d.logf("%s: generated, counting anyway", f.Path)
+		reason = ""
+	}
+	if reason != "" && !sc.UnignoreContents {
+		sc.d.logf("%s: contents should be ignored (%s), skipping", f.Path, reason)
+		f.IgnoreReason = reason
+		sc.report(f)
+		return
+	}
+	if !sc.takeSample() {
+		sc.d.logf("%s is beyond MaxFiles, skipping", f.Path)
+		return
+	}
+
+	if language := j.attrs["linguist-language"]; language != "" {
+		sc.d.logf("%s got result by gitattributes: %s", f.Path, language)
+		f.Language = language
+	} else {
+		language, reason, ok := sc.detect(f.Path, contents)
+		if reason != "" {
+			sc.d.logf("%s: should be ignored according to post processors (%s), skipping", f.Path, reason)
+			f.IgnoreReason = reason
+			sc.report(f)
+			return
+		}
+		if !ok {
+			sc.d.logf("%s: not detected within %s, counting as unknown", f.Path, sc.Timeout)
+		}
+		f.Language = language
+		if f.Language == "" {
+			f.Language = UnknownLanguage
+		}
+	}
+
+	switch {
+	case f.Language == "HTML" && sc.SplitEmbedded:
+		if full := sc.readFull(j, &f); full != nil {
+			f.Sizes = SplitHTML(full)
+			sc.d.logf("%s split into %v", f.Path, f.Sizes)
+		}
+	case sc.SplitFrontMatter && hasFrontMatter(f.Language, contents):
+		if full := sc.readFull(j, &f); full != nil {
+			fmLanguage, fmSize := FrontMatter(full)
+			sc.d.logf("%s has %d bytes of %s front matter", f.Path, fmSize, fmLanguage)
+			f.Sizes = map[string]int{f.Language: len(full) - fmSize}
+			if fmSize > 0 {
+				f.Sizes[fmLanguage] += fmSize
+			}
+		}
+	}
+	sc.report(f)
+}
+
+// readFull reads all of a file to be split. If that fails after the start of
+// the file was read, the error is noted in f, which is still counted as a
+// whole.
+func (sc *scan) readFull(j *scanJob, f *ScannedFile) []byte {
+	contents, err := j.read(true)
+	if err != nil {
+		sc.d.logf("%s: could not be read, skipping: %v", f.Path, err)
+		f.Err = err
+		return nil
+	}
+	return contents
+}
+
+// detect determines the language of a file, giving up after Timeout, in
+// which case ok is false, or returns the reason the file should be ignored
+// according to post processors. Detection carries on in the background until
+// it is done, as neither regular expressions nor the classifier can be
+// interrupted, but the scan no longer waits for it, unless Threads detections
+// given up on are still running.
+func (sc *scan) detect(path string, contents []byte) (language, reason string, ok bool) {
+	type detected struct{ language, reason string }
+	detect := func() detected {
+		if len(sc.d.postProcessors) == 0 {
+			return detected{sc.d.Detect(path, contents), ""}
+		}
+		before := sc.d.analyze(path, contents)
+		after := sc.d.postProcess(before)
+		return detected{after.Language, sc.postIgnoreReason(before, after)}
+	}
+	if sc.Timeout <= 0 {
+		d := detect()
+		return d.language, d.reason, true
+	}
+	sc.detections <- struct{}{}
+	result := make(chan detected, 1)
+	go func() {
+		defer func() { <-sc.detections }()
+		result <- detect()
+	}()
+	select {
+	case d := <-result:
+		return d.language, d.reason, true
+	case <-time.After(sc.Timeout):
+		return "", "", false
+	}
+}
+
+// postIgnoreReason returns the reason a file should be ignored because a post
+// processor flagged it as such, given the Options
+func (sc *scan) postIgnoreReason(before, after FileInfo) string {
+	switch {
+	case after.IsVendored && !before.IsVendored && !sc.UnignoreFilenames:
+		return IgnoredVendored
+	case after.IsDocumentation && !before.IsDocumentation && !sc.UnignoreFilenames:
+		return IgnoredDocumentation
+	case after.IsBinary && !before.IsBinary && !sc.UnignoreContents:
+		return IgnoredBinary
+	case after.IsGenerated && !before.IsGenerated && !sc.UnignoreContents && !sc.CountGenerated:
+		return IgnoredGenerated
+	}
+	return ""
+}
+
+// hasFrontMatter reports whether SplitFrontMatter applies to a file of
+// language starting with contents: a prose or markup file, such as Markdown,
+// starting with a front matter fence
+func hasFrontMatter(language string, contents []byte) bool {
+	switch LanguageType(language) {
+	case "prose", "markup":
+		return bytes.HasPrefix(contents, []byte("---")) || bytes.HasPrefix(contents, []byte("+++"))
+	}
+	return false
+}
+
+// takeSample reports whether another file may be counted under MaxFiles,
+// counting it if so, or else noting that the result is sampled
+func (sc *scan) takeSample() bool {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if sc.MaxFiles > 0 && sc.counted >= sc.MaxFiles {
+		sc.sampled = true
+		return false
+	}
+	sc.counted++
+	return true
+}
+
+// isSampled reports whether a file which would have been counted was left
+// out because of MaxFiles, so that the walk can stop looking for more. Until
+// then, the rest of the files may all turn out to be ignored, in which case
+// the result is complete rather than sampled.
+func (sc *scan) isSampled() bool {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	return sc.sampled
+}
+
+// report adds f to the result and passes it to Report
+func (sc *scan) report(f ScannedFile) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	switch {
+	case f.IgnoreReason != "":
+		sc.ignored++
+	case f.Language != "":
+		f.Counted = sc.countedSizes(f)
+		for language, size := range f.Counted {
+			sc.sizes[language] += size
+		}
+		sc.files[f.Language]++
+	}
+	if f.Err != nil {
+		sc.unreadable++
+	}
+	if sc.Report != nil {
+		sc.Report(f)
+	}
+}
+
+// countedSizes returns the bytes of f to count towards each language: all of
+// it towards its Language, or its Sizes if it was split, with CapFileSize
+// applied to the file as a whole and then Weight. Rounding down the share of
+// each language, what is left of the cap goes to Language.
+func (sc *scan) countedSizes(f ScannedFile) map[string]int {
+	sizes := f.Sizes
+	if sizes == nil {
+		sizes = map[string]int{f.Language: f.Size}
+	}
+	total := 0
+	for _, size := range sizes {
+		total += size
+	}
+	counted := make(map[string]int, len(sizes))
+	for language, size := range sizes {
+		counted[language] = size
+	}
+	if sc.CapFileSize > 0 && total > sc.CapFileSize {
+		left := sc.CapFileSize
+		for language, size := range sizes {
+			counted[language] = size * sc.CapFileSize / total
+			left -= counted[language]
+		}
+		counted[f.Language] += left
+	}
+	if sc.Weight != nil {
+		weight := sc.Weight(f.Path)
+		for language, size := range counted {
+			counted[language] = int(float64(size) * weight)
+		}
+	}
+	return counted
+}
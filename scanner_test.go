@@ -0,0 +1,449 @@
+package linguist
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// writeTree creates the files, with the given contents, below root
+func writeTree(t testing.TB, root string, files map[string]string) {
+	t.Helper()
+	for name, contents := range files {
+		filename := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filename, []byte(contents), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// scanReports scans root with options, returning the result and everything
+// reported by path
+func scanReports(t *testing.T, root string, options Options) (Result, map[string]ScannedFile) {
+	t.Helper()
+	reports := map[string]ScannedFile{}
+	options.Report = func(f ScannedFile) {
+		if _, ok := reports[f.Path]; ok {
+			t.Errorf("%s reported twice", f.Path)
+		}
+		reports[f.Path] = f
+	}
+	result, err := NewScanner(root, options).Scan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result, reports
+}
+
+func TestScanner(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		".git/info/attributes": "misc.txt linguist-language=Python\n",
+		".gitignore":           "build/\n*.log\n",
+		".gitattributes":       "gen.go linguist-generated\nmisc.txt linguist-language=Go\ntools export-ignore\n",
+		"main.go":              "package main\n\nfunc main() {}\n",
+		"main_test.go":         "package main\n",
+		"secret.go":            "package main\n",
+		"sub/.gitignore":       "secret.go\n",
+		"sub/secret.go":        "package sub\n",
+		"sub/keep.go":          "package sub\n",
+		"build/out.go":         "package build\n",
+		"a.log":                "log\n",
+		"gen.go":               "package main\n",
+		"misc.txt":             "print(1)\n",
+		"vendor/lib.go":        "package lib\n",
+		"tools/tool.go":        "package tools\n",
+		"skip.go":              "package main\n",
+		"mystery":              "zzz qqq\n",
+		"empty.go":             "",
+		"deep/a/b.go":          "package a\n",
+	})
+	options, err := DefaultOptions(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := &Detector{}
+	// the classifier guesses a language for anything, which this leaves
+	// undetected
+	d.AddPostProcessor(func(fi FileInfo) FileInfo {
+		if fi.Path == "mystery" {
+			fi.Language = ""
+		}
+		return fi
+	})
+	options.Detector = d
+	options.RespectExportIgnore = true
+	options.ExcludeTests = true
+	options.MaxDepth = 2
+	options.Ignore = func(path string, isDir bool) bool { return path == "skip.go" }
+	result, reports := scanReports(t, root, options)
+
+	for path, want := range map[string]ScannedFile{
+		"build":         {IsDir: true, IgnoreReason: IgnoredGitIgnore},
+		"a.log":         {IgnoreReason: IgnoredGitIgnore},
+		"sub/secret.go": {IgnoreReason: IgnoredGitIgnore},
+		"vendor/lib.go": {IgnoreReason: IgnoredVendored, ThirdParty: true},
+		"gen.go":        {IgnoreReason: IgnoredGenerated, ThirdParty: true},
+		"tools/tool.go": {IgnoreReason: IgnoredExport},
+		"main_test.go":  {IgnoreReason: IgnoredTest},
+		"skip.go":       {IgnoreReason: IgnoredOption},
+		"main.go":       {Language: "Go"},
+		"secret.go":     {Language: "Go"},
+		"sub/keep.go":   {Language: "Go"},
+		"misc.txt":      {Language: "Python"},
+		"mystery":       {Language: UnknownLanguage},
+		// dotfiles are vendored
+		".gitignore":     {IgnoreReason: IgnoredVendored, ThirdParty: true},
+		".gitattributes": {IgnoreReason: IgnoredVendored, ThirdParty: true},
+		"sub/.gitignore": {IgnoreReason: IgnoredVendored, ThirdParty: true},
+	} {
+		got, ok := reports[path]
+		if !ok {
+			t.Errorf("%s: not reported, want %+v", path, want)
+			continue
+		}
+		if got.IsDir != want.IsDir || got.IgnoreReason != want.IgnoreReason || got.ThirdParty != want.ThirdParty || got.Language != want.Language {
+			t.Errorf("%s: got %+v, want %+v", path, got, want)
+		}
+		delete(reports, path)
+	}
+	for path, got := range reports {
+		t.Errorf("%s: unexpected %+v", path, got)
+	}
+
+	if got := result.Language("Go"); got == nil || got.Files != 3 {
+		t.Errorf("Go: got %+v, want 3 files", got)
+	}
+	if result.Language(UnknownLanguage) == nil {
+		t.Errorf("%s is not counted", UnknownLanguage)
+	}
+	if result.IgnoredPaths != 11 {
+		t.Errorf("IgnoredPaths = %d, want 11", result.IgnoredPaths)
+	}
+	if result.Sampled || result.Unreadable != 0 {
+		t.Errorf("Sampled = %v, Unreadable = %d, want false and 0", result.Sampled, result.Unreadable)
+	}
+}
+
+func TestScannerUnignore(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"main.go":       "package main\n",
+		"vendor/lib.go": "package lib\n",
+		"empty.go":      "",
+	})
+	_, reports := scanReports(t, root, Options{UnignoreFilenames: true, IgnoreEmpty: true})
+	if got := reports["vendor/lib.go"]; got.Language != "Go" || !got.ThirdParty {
+		t.Errorf("vendor/lib.go with UnignoreFilenames: got %+v", got)
+	}
+	if got := reports["empty.go"]; got.IgnoreReason != IgnoredEmpty {
+		t.Errorf("empty.go with IgnoreEmpty: got %+v", got)
+	}
+}
+
+func TestScannerMaxFiles(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"a.go":       "package a\n",
+		"b.go":       "package a\n",
+		"c.go":       "package a\n",
+		"vendor/x.c": "int x;\n",
+	})
+	for _, tt := range []struct {
+		maxFiles int
+		files    int
+		sampled  bool
+	}{
+		{2, 2, true},
+		// the last file is ignored, so nothing was left out
+		{3, 3, false},
+		{0, 3, false},
+	} {
+		// one file at a time, so that the files counted are the first ones
+		result, err := NewScanner(root, Options{MaxFiles: tt.maxFiles}).Scan()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.TotalFiles != tt.files || result.Sampled != tt.sampled {
+			t.Errorf("MaxFiles %d: %d files, sampled %v, want %d, %v", tt.maxFiles, result.TotalFiles, result.Sampled, tt.files, tt.sampled)
+		}
+	}
+}
+
+func TestScannerCapFileSize(t *testing.T) {
+	root := t.TempDir()
+	html := "<html><script>" + strings.Repeat("x = 1;\n", 30) + "</script><style>" + strings.Repeat("p {}\n", 30) + "</style></html>\n"
+	writeTree(t, root, map[string]string{
+		"index.html": html,
+		"big.go":     "package big\n" + strings.Repeat("// padding\n", 100),
+		"small.py":   "x = 1\n",
+	})
+	result, reports := scanReports(t, root, Options{CapFileSize: 100, SplitEmbedded: true})
+	// the split file is capped as a whole, not each of its languages
+	counted := 0
+	for _, size := range reports["index.html"].Counted {
+		counted += size
+	}
+	if counted != 100 || len(reports["index.html"].Counted) != 3 {
+		t.Errorf("index.html: counted %v, want 100 bytes of HTML, JavaScript and CSS", reports["index.html"].Counted)
+	}
+	if got := reports["index.html"].Sizes["JavaScript"]; got != 30*7 {
+		t.Errorf("index.html: Sizes has %d bytes of JavaScript, want all %d", got, 30*7)
+	}
+	if result.TotalSize != 100+100+6 {
+		t.Errorf("TotalSize = %d, want %d", result.TotalSize, 100+100+6)
+	}
+	if got := result.Language("Python"); got == nil || got.Size != 6 {
+		t.Errorf("Python: got %+v, want 6 bytes", got)
+	}
+}
+
+func TestScannerWeight(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"new.go": "package main\n" + strings.Repeat("// padding\n", 100),
+		"old.py": "x = 1\n",
+	})
+	weight := func(path string) float64 {
+		if path == "new.go" {
+			return 0.5
+		}
+		return 2
+	}
+	// weighted after the cap
+	result, reports := scanReports(t, root, Options{CapFileSize: 100, Weight: weight})
+	if got := reports["new.go"].Counted["Go"]; got != 50 {
+		t.Errorf("new.go: counted %d bytes, want 50", got)
+	}
+	if result.TotalSize != 50+12 {
+		t.Errorf("TotalSize = %d, want %d", result.TotalSize, 50+12)
+	}
+}
+
+func TestScannerTimeout(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"fast.go": "package main\n",
+		"slow.go": "package main\n",
+	})
+	d := &Detector{}
+	// a classifier that takes far longer than the timeout on one file
+	d.AddPostProcessor(func(fi FileInfo) FileInfo {
+		if fi.Path == "slow.go" {
+			time.Sleep(500 * time.Millisecond)
+		}
+		return fi
+	})
+	result, reports := scanReports(t, root, Options{Detector: d, Timeout: 50 * time.Millisecond})
+	if got := reports["slow.go"].Language; got != UnknownLanguage {
+		t.Errorf("slow.go: got %q, want %s", got, UnknownLanguage)
+	}
+	if got := reports["fast.go"].Language; got != "Go" {
+		t.Errorf("fast.go: got %q, want Go", got)
+	}
+	if got := result.Language(UnknownLanguage); got == nil || got.Files != 1 {
+		t.Errorf("%s: got %+v, want 1 file", UnknownLanguage, got)
+	}
+}
+
+func TestScannerTimeoutAbandoned(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{}
+	for _, name := range []string{"a.go", "b.go", "c.go", "d.go", "e.go"} {
+		files[name] = "package main\n"
+	}
+	writeTree(t, root, files)
+	var mu sync.Mutex
+	running, most := 0, 0
+	d := &Detector{}
+	d.AddPostProcessor(func(fi FileInfo) FileInfo {
+		mu.Lock()
+		if running++; running > most {
+			most = running
+		}
+		mu.Unlock()
+		time.Sleep(100 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return fi
+	})
+	result, _ := scanReports(t, root, Options{Detector: d, Threads: 1, Timeout: 10 * time.Millisecond})
+	if got := result.Language(UnknownLanguage); got == nil || got.Files != 5 {
+		t.Errorf("%s: got %+v, want 5 files", UnknownLanguage, got)
+	}
+	// the one being classified, and one given up on
+	mu.Lock()
+	defer mu.Unlock()
+	if most > 2 {
+		t.Errorf("%d detections ran at once with 1 thread, want at most 2", most)
+	}
+}
+
+func TestScannerWalk(t *testing.T) {
+	denied := errors.New("permission denied")
+	walk := func(visit func(Entry) error) error {
+		read := func(contents string) func(bool) ([]byte, error) {
+			return func(bool) ([]byte, error) { return []byte(contents), nil }
+		}
+		for _, e := range []Entry{
+			{Path: "locked", IsDir: true, Err: denied},
+			{Path: "broken.go", Size: 1, Read: func(bool) ([]byte, error) { return nil, denied }},
+			{Path: "a.go", Size: 10, Read: read("package a\n")},
+			{Path: "deep", IsDir: true},
+			{Path: "deep/b.go", Size: 10, Read: read("package b\n")},
+		} {
+			if err := visit(e); err == filepath.SkipDir && e.Path == "deep" {
+				t.Errorf("%s: SkipDir", e.Path)
+			} else if err != nil && err != filepath.SkipDir {
+				return err
+			}
+		}
+		return nil
+	}
+	var listed []string
+	result, err := NewScanner("", Options{Walk: walk, ReadThreads: 2, Threads: 2}).Scan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.TotalFiles != 2 || result.Unreadable != 2 {
+		t.Errorf("got %d files and %d unreadable, want 2 and 2", result.TotalFiles, result.Unreadable)
+	}
+
+	_, err = NewScanner("", Options{Walk: walk, List: func(path string) {
+		listed = append(listed, path)
+	}}).Scan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(listed) != 3 {
+		t.Errorf("listed %v, want broken.go, a.go and deep/b.go", listed)
+	}
+}
+
+func TestScannerUnreadable(t *testing.T) {
+	denied := errors.New("permission denied")
+	walk := func(visit func(Entry) error) error {
+		for _, e := range []Entry{
+			{Path: "a.go", Size: 10, Read: func(bool) ([]byte, error) { return []byte("package a\n"), nil }},
+			{Path: "secret.py", Size: 6, Read: func(bool) ([]byte, error) { return nil, denied }},
+			{Path: "b.go", Size: 10, Read: func(bool) ([]byte, error) { return []byte("package b\n"), nil }},
+		} {
+			if err := visit(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	result, reports := scanReports(t, "", Options{Walk: walk})
+	// skipped, and the scan carries on
+	if got := reports["secret.py"]; got.Err != denied || got.Language != "" {
+		t.Errorf("secret.py: got %+v, want skipped with %v", got, denied)
+	}
+	if result.Unreadable != 1 || result.TotalFiles != 2 || result.Language("Python") != nil {
+		t.Errorf("got %d unreadable of %d files, %v, want 1 of 2, only Go", result.Unreadable, result.TotalFiles, result.Languages)
+	}
+}
+
+// slowWalk walks n Go files whose reads each take latency, as on a network
+// file system or a cold disk
+func slowWalk(n int, latency time.Duration) WalkFunc {
+	contents := []byte("package main\n\nfunc main() {}\n")
+	read := func(bool) ([]byte, error) {
+		time.Sleep(latency)
+		return contents, nil
+	}
+	return func(visit func(Entry) error) error {
+		for i := 0; i < n; i++ {
+			if err := visit(Entry{Path: fmt.Sprintf("f%d.go", i), Size: len(contents), Read: read}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// Reading is the bottleneck here, so more ReadThreads help while more
+// Threads do not.
+func BenchmarkScanSlowReads(b *testing.B) {
+	for _, tt := range []struct{ readThreads, threads int }{
+		{1, 1},
+		{1, 8},
+		{8, 1},
+		{8, 8},
+	} {
+		b.Run(fmt.Sprintf("io%d-cpu%d", tt.readThreads, tt.threads), func(b *testing.B) {
+			options := Options{
+				Walk:           slowWalk(100, time.Millisecond),
+				ReadThreads:    tt.readThreads,
+				Threads:        tt.threads,
+				ReadBuffer:     64,
+				ClassifyBuffer: 64,
+			}
+			for i := 0; i < b.N; i++ {
+				if _, err := NewScanner("", options).Scan(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// syntheticTree returns the files of a project of about n files, mixing
+// languages detected by name, by heuristics and by the classifier with
+// vendored, generated, ignored and binary files
+func syntheticTree(n int) map[string]string {
+	templates := []struct{ name, contents string }{
+		{"pkg%d/file.go", "package pkg\n\nimport \"fmt\"\n\nfunc F() { fmt.Println(%d) }\n"},
+		{"lib%d/module.py", "import os\n\n\ndef f():\n    return os.getcwd() + str(%d)\n"},
+		{"include%d/header.h", "#include <vector>\nnamespace n { std::vector<int> v(%d); }\n"},
+		{"web%d/index.html", "<html><script>var x = %d;</script><style>p { }</style></html>\n"},
+		{"docs%d/page.md", "---\ntitle: Page\n---\n# Page %d\n"},
+		{"scripts%d/run", "#!/bin/sh\necho %d\n"},
+		{"src%d/mystery", "(define (f x) (* x %d))\n"},
+		{"vendor/dep%d/dep.js", "module.exports = %d;\n"},
+		{"gen%d/api.pb.go", "// Code generated by protoc-gen-go. DO NOT EDIT.\npackage api // %d\n"},
+		{"build%d/out.o", "\x7fELF\x02\x01\x01\x03%d"},
+		{"logs%d/app.log", "line %d\n"},
+	}
+	files := map[string]string{
+		".gitignore":     "*.log\n",
+		".gitattributes": "src*/mystery linguist-language=Scheme\n",
+	}
+	for i := 0; len(files) < n; i++ {
+		t := templates[i%len(templates)]
+		files[fmt.Sprintf(t.name, i/len(templates))] = strings.Repeat(fmt.Sprintf(t.contents, i), 1+i%10)
+	}
+	return files
+}
+
+// The whole Scanner, from walking the tree to classifying and splitting
+// files, over a synthetic tree on disk.
+func BenchmarkScan(b *testing.B) {
+	root := b.TempDir()
+	writeTree(b, root, syntheticTree(1000))
+	if err := os.Mkdir(filepath.Join(root, ".git"), 0777); err != nil {
+		b.Fatal(err)
+	}
+	options, err := DefaultOptions(root)
+	if err != nil {
+		b.Fatal(err)
+	}
+	options.SplitEmbedded = true
+	options.SplitFrontMatter = true
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := NewScanner(root, options).Scan(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package linguist
+
+import (
+	"bytes"
+	"regexp"
+)
+
+// A sniffer recognizes a kind of file by its contents alone, even where its
+// name suggests another language, and is used by Detector in content
+// priority mode (see Detector.ContentPriority).
+type sniffer struct {
+	language string
+	// languages suggested by the file's name (see LanguageHints) which may be
+	// overridden, with "" standing for files whose name suggests nothing
+	overrides []string
+	match     func(contents []byte) bool
+}
+
+var sniffers = []sniffer{
+	{"TSV", []string{"", "Text"}, delimited('\t')},
+	{"CSV", []string{"", "Text"}, delimited(',')},
+
+	// API specifications and schemas, rather than any other JSON or YAML,
+	// by the version or dialect they declare
+	{"OASv3-yaml", []string{"YAML"}, matching(`(?m)^openapi:\s*['"]?3\.\d`)},
+	{"OASv2-yaml", []string{"YAML"}, matching(`(?m)^swagger:\s*['"]?2\.0`)},
+	{"OASv3-json", []string{"JSON"}, matching(`"openapi"\s*:\s*"3\.\d`)},
+	{"OASv2-json", []string{"JSON"}, matching(`"swagger"\s*:\s*"2\.0`)},
+	{"JSON Schema", []string{"JSON"}, matching(`"\$schema"\s*:\s*"https?://json-schema\.org/`)},
+}
+
+// Returns the language of the first sniffer which matches contents and may
+// override hints.
+func languageBySniffing(contents []byte, hints []string) string {
+	for _, s := range sniffers {
+		if !s.overridesHints(hints) {
+			continue
+		}
+		if s.match(contents) {
+			return s.language
+		}
+	}
+	return ""
+}
+
+func (s sniffer) overridesHints(hints []string) bool {
+	for _, o := range s.overrides {
+		if (o == "" && len(hints) == 0) || (o != "" && hinted(hints, o)) {
+			return true
+		}
+	}
+	return false
+}
+
+// matching returns a function checking that contents match pattern.
+func matching(pattern string) func(contents []byte) bool {
+	return regexp.MustCompile(pattern).Match
+}
+
+// delimited returns a function checking that there are at least three lines
+// of contents, each with the same (non-zero) number of fields separated by
+// sep, outside of double quotes.
+func delimited(sep byte) func(contents []byte) bool {
+	return func(contents []byte) bool {
+		lines := splitLines(contents)
+		if len(lines) > 0 && len(contents) >= 512 && !bytes.HasSuffix(contents, []byte("\n")) && !bytes.HasSuffix(contents, []byte("\r")) {
+			// the last line is likely cut short by ReadHead
+			lines = lines[:len(lines)-1]
+		}
+		fields, n := -1, 0
+		for _, line := range lines {
+			if len(line) == 0 {
+				continue
+			}
+			count, quoted := 0, false
+			for _, c := range line {
+				switch {
+				case c == '"':
+					quoted = !quoted
+				case c == sep && !quoted:
+					count++
+				}
+			}
+			if count == 0 || (fields >= 0 && count != fields) {
+				return false
+			}
+			fields = count
+			n++
+		}
+		return n >= 3
+	}
+}
+
+// splitLines splits contents into lines ending in "\n", "\r\n" or "\r".
+func splitLines(contents []byte) [][]byte {
+	contents = bytes.TrimSuffix(normalizeEOL(contents), []byte("\n"))
+	if len(contents) == 0 {
+		return nil
+	}
+	return bytes.Split(contents, []byte("\n"))
+}
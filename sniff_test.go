@@ -0,0 +1,54 @@
+package linguist
+
+import "testing"
+
+// testSniff is testDetect in content priority mode
+func testSniff(t *testing.T, cases []detectCase) {
+	t.Helper()
+	d := &Detector{ContentPriority: true}
+	for _, tt := range cases {
+		if got := d.Detect(tt.filename, []byte(tt.contents)); got != tt.want {
+			t.Errorf("Detect(%q, %q) in content priority mode = %q, want %q", tt.filename, tt.contents, got, tt.want)
+		}
+	}
+}
+
+func TestSniffDelimited(t *testing.T) {
+	testSniff(t, []detectCase{
+		{"export", "name,age,city\nada,36,london\nalan,41,\"wilmslow, cheshire\"\n", "CSV"},
+		{"export.txt", "name\tage\tcity\nada\t36\tlondon\nalan\t41\twilmslow\n", "TSV"},
+		{"export.txt", "name,age\r\nada,36\r\nalan,41\r\n", "CSV"},
+		// the fields don't line up
+		{"notes.txt", "Hello, world.\nThis is just text, with commas, here and there.\nThe end\n", "Text"},
+		// too short to tell
+		{"pair.txt", "a,b\n1,2\n", "Text"},
+		// other languages are not overridden
+		{"main.go", "a,b,c\n1,2,3\n4,5,6\n", "Go"},
+	})
+	testDetect(t, []detectCase{
+		{"export.csv", "name,age\nada,36\n", "CSV"},
+		{"export.tsv", "name\tage\nada\t36\n", "TSV"},
+	})
+}
+
+func TestSniffAPISpecs(t *testing.T) {
+	testSniff(t, []detectCase{
+		{"api/openapi.yaml", "openapi: 3.0.3\ninfo:\n  title: Pets\n  version: 1.0.0\npaths: {}\n", "OASv3-yaml"},
+		{"api/pets.yml", "openapi: \"3.1.0\"\ninfo:\n  title: Pets\n", "OASv3-yaml"},
+		{"api/swagger.yaml", "swagger: '2.0'\ninfo:\n  title: Pets\n", "OASv2-yaml"},
+		{"api/openapi.json", "{\n  \"openapi\": \"3.0.0\",\n  \"info\": {\"title\": \"Pets\"}\n}\n", "OASv3-json"},
+		{"api/swagger.json", "{\"swagger\": \"2.0\", \"paths\": {}}\n", "OASv2-json"},
+		{"schema/pet.json", "{\n  \"$schema\": \"https://json-schema.org/draft/2020-12/schema\",\n  \"type\": \"object\"\n}\n", "JSON Schema"},
+		// other configuration is left alone
+		{"config.yaml", "server:\n  port: 8080\n", "YAML"},
+		{"package.json", "{\"name\": \"app\", \"version\": \"1.0.0\"}\n", "JSON"},
+		{"schema/other.json", "{\"$schema\": \"https://example.com/schema\"}\n", "JSON"},
+	})
+	// only in content priority mode
+	testDetect(t, []detectCase{
+		{"schema/pet.json", "{\n  \"$schema\": \"https://json-schema.org/draft/2020-12/schema\"\n}\n", "JSON"},
+	})
+	if got := LanguageGroup("OASv3-yaml"); got != "OpenAPI Specification v3" {
+		t.Errorf("LanguageGroup(OASv3-yaml) = %q, want OpenAPI Specification v3", got)
+	}
+}
@@ -478,6 +478,19 @@ AutoIt:
   tm_scope: source.autoit
   ace_mode: autohotkey
   language_id: 27
+Automake:
+  type: programming
+  group: Makefile
+  extensions:
+  - ".am"
+  filenames:
+  - GNUmakefile.am
+  - Makefile.am
+  tm_scope: source.makefile
+  ace_mode: makefile
+  codemirror_mode: cmake
+  codemirror_mime_type: text/x-cmake
+  language_id: 384258223
 Avro IDL:
   type: data
   color: "#0040FF"
@@ -784,6 +797,7 @@ C++:
   - ".tcc"
   - ".tpp"
   - ".txx"
+  - ".C"
   language_id: 43
 C-ObjDump:
   type: data
@@ -1459,6 +1473,14 @@ DNS Zone:
   tm_scope: text.zone_file
   ace_mode: text
   language_id: 84
+Docker Interactive Notebook:
+  type: markup
+  ace_mode: docker
+  color: "#7582D1"
+  extensions:
+  - ".idnb"
+  tm_scope: Untitled.idnb
+  language_id: 422
 DTrace:
   type: programming
   aliases:
@@ -1942,6 +1964,16 @@ FIGlet Font:
   tm_scope: source.figfont
   ace_mode: text
   language_id: 686129783
+FlatBuffers:
+  type: data
+  aliases:
+  - flatbuffer
+  - fbs
+  extensions:
+  - ".fbs"
+  tm_scope: none
+  ace_mode: text
+  language_id: 531150412
 FLUX:
   type: programming
   color: "#88ccff"
@@ -2631,6 +2663,9 @@ Groovy:
   - groovy
   filenames:
   - Jenkinsfile
+  - build.gradle
+  - init.gradle
+  - settings.gradle
   language_id: 142
 Groovy Server Pages:
   type: programming
@@ -2672,6 +2707,9 @@ HCL:
   codemirror_mode: ruby
   codemirror_mime_type: text/x-ruby
   tm_scope: source.terraform
+  filenames:
+  - ".terraformrc"
+  - terraform.rc
   language_id: 144
 HLSL:
   type: programming
@@ -2740,6 +2778,8 @@ HTML+EEX:
   - ".eex"
   - ".html.heex"
   - ".html.leex"
+  - ".heex"
+  - ".leex"
   ace_mode: text
   codemirror_mode: htmlmixed
   codemirror_mime_type: text/html
@@ -3150,6 +3190,14 @@ JFlex:
   tm_scope: source.jflex
   ace_mode: text
   language_id: 173
+JSON Schema:
+  type: data
+  color: "#292929"
+  tm_scope: source.json
+  ace_mode: json
+  codemirror_mode: javascript
+  codemirror_mime_type: application/json
+  language_id: 1014207601
 JSON:
   type: data
   color: "#292929"
@@ -3606,6 +3654,9 @@ Kotlin:
   ace_mode: text
   codemirror_mode: clike
   codemirror_mime_type: text/x-kotlin
+  filenames:
+  - build.gradle.kts
+  - settings.gradle.kts
   language_id: 189
 Kusto:
   type: data
@@ -3940,6 +3991,7 @@ M4Sugar:
   - ".m4"
   filenames:
   - configure.ac
+  - configure.in
   tm_scope: source.m4
   ace_mode: text
   language_id: 216
@@ -4054,7 +4106,6 @@ Makefile:
   - GNUmakefile
   - Kbuild
   - Makefile
-  - Makefile.am
   - Makefile.boot
   - Makefile.frag
   - Makefile.in
@@ -4204,6 +4255,7 @@ Meson:
   filenames:
   - meson.build
   - meson_options.txt
+  - meson.options
   tm_scope: source.meson
   ace_mode: text
   language_id: 799141244
@@ -6530,6 +6582,7 @@ Shell:
   - zprofile
   - zshenv
   - zshrc
+  - ".envrc"
   interpreters:
   - ash
   - bash
@@ -8335,14 +8388,6 @@ xBase:
   tm_scope: source.harbour
   ace_mode: text
   language_id: 421
-Docker Interactive Notebook:
-  type: markup
-  ace_mode: docker
-  color: "#7582D1"
-  extensions:
-  - ".idnb"
-  tm_scope: Untitled.idnb 
-  language_id: 422
 `,
 
 	"data/vendor.yml": `# Vendored files and directories are excluded from language
@@ -8738,6 +8783,10 @@ Docker Interactive Notebook:
 
 # teamcity CI configuration
 - (^|/)\.teamcity/
+
+# Dart and Flutter tool caches and packages
+- (^|/)\.dart_tool/
+- (^|/)\.pub-cache/
 `,
 
 	"data/documentation.yml": `# Documentation files and directories are excluded from language